@@ -1,10 +1,20 @@
 package mysql
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
 	"github.com/funny/utest"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
 	"strconv"
+	"sync"
 	"testing"
+	"time"
 )
 
 var TestConnParam ConnectionParams
@@ -171,6 +181,509 @@ func Test_Prepare(t *testing.T) {
 	stmt.Close()
 }
 
+func Test_BindCgoCheck(t *testing.T) {
+	// Run this test again in a child process with the strict cgo pointer checking.
+	// GODEBUG=cgocheck=2 is not supported since Go 1.21, so build the child with GOEXPERIMENT=cgocheck2.
+	if os.Getenv("TEST_MYSQL_CGOCHECK") == "" {
+		gotool, err := exec.LookPath("go")
+		if err != nil {
+			t.Skip("go command not found, can't build the test with GOEXPERIMENT=cgocheck2")
+		}
+		bin := filepath.Join(t.TempDir(), "mysql.test")
+		build := exec.Command(gotool, "test", "-c", "-o", bin, ".")
+		build.Env = append(os.Environ(), "GOEXPERIMENT=cgocheck2")
+		if out, err := build.CombinedOutput(); err != nil {
+			t.Fatalf("%v\n%s", err, out)
+		}
+		cmd := exec.Command(bin, "-test.run=^Test_BindCgoCheck$")
+		cmd.Env = append(os.Environ(), "TEST_MYSQL_CGOCHECK=1")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%v\n%s", err, out)
+		}
+		return
+	}
+
+	conn, err := Connect(TestConnParam)
+	utest.IsNilNow(t, err)
+	defer conn.Close()
+
+	stmt, err := conn.Prepare("INSERT INTO test VALUES(?, ?)")
+	utest.IsNilNow(t, err)
+	defer stmt.Close()
+
+	var id int32
+	for i := 100; i < 110; i++ {
+		stmt.CleanBind()
+		stmt.Bind(&id)
+		stmt.Bind(strconv.Itoa(i))
+
+		// *int32 is bound by reference, the value is read when execute.
+		// the copied data must not be affected by GC
+		id = int32(i)
+		runtime.GC()
+
+		res, err := stmt.Execute()
+		utest.IsNilNow(t, err)
+		utest.EqualNow(t, res.RowsAffected(), 1)
+	}
+
+	query, err := conn.Prepare("SELECT value FROM test WHERE id = ?")
+	utest.IsNilNow(t, err)
+	defer query.Close()
+
+	for i := 100; i < 110; i++ {
+		query.CleanBind()
+		query.BindBigInt(int64(i))
+		runtime.GC()
+
+		table, err := query.QueryTable()
+		utest.IsNilNow(t, err)
+		utest.EqualNow(t, len(table.Rows()), 1)
+		utest.EqualNow(t, table.Rows()[0][0].String(), strconv.Itoa(i))
+	}
+
+	_, err = conn.Execute("DELETE FROM test WHERE id >= 100")
+	utest.IsNilNow(t, err)
+}
+
+func Test_QueryMulti(t *testing.T) {
+	param := TestConnParam
+	param.Flags = CF_CLIENT_MULTI_STATEMENTS
+
+	conn, err := Connect(param)
+	utest.IsNilNow(t, err)
+	defer conn.Close()
+
+	res, err := conn.QueryMulti("SELECT id FROM test WHERE id < 3 ORDER BY id; UPDATE test SET value = value WHERE id < 5; SELECT 1, 2")
+	utest.IsNilNow(t, err)
+
+	table, ok := res.NextResult()
+	utest.EqualNow(t, ok, true)
+	utest.EqualNow(t, len(table.Fields()), 1)
+	utest.EqualNow(t, len(table.Rows()), 3)
+	utest.EqualNow(t, table.Rows()[2][0].Int64(), int64(2))
+
+	table, ok = res.NextResult()
+	utest.EqualNow(t, ok, true)
+	utest.EqualNow(t, len(table.Fields()), 0)
+	utest.EqualNow(t, len(table.Rows()), 0)
+
+	table, ok = res.NextResult()
+	utest.EqualNow(t, ok, true)
+	utest.EqualNow(t, len(table.Fields()), 2)
+	utest.EqualNow(t, table.Rows()[0][1].Int64(), int64(2))
+
+	_, ok = res.NextResult()
+	utest.Assert(t, !ok)
+	utest.IsNilNow(t, res.Err())
+	res.Close()
+
+	// discard the remaining results
+	res, err = conn.QueryMulti("SELECT 1; SELECT 2; SELECT 3")
+	utest.IsNilNow(t, err)
+	res.Close()
+
+	_, err = conn.Execute("DROP PROCEDURE IF EXISTS test_multi")
+	utest.IsNilNow(t, err)
+
+	_, err = conn.Execute(`CREATE PROCEDURE test_multi(IN n INT)
+	BEGIN
+		SELECT id FROM test WHERE id < n ORDER BY id;
+		SELECT id, value FROM test WHERE id = n;
+	END`)
+	utest.IsNilNow(t, err)
+
+	stmt, err := conn.Prepare("CALL test_multi(?)")
+	utest.IsNilNow(t, err)
+	defer stmt.Close()
+
+	stmt.BindInt(4)
+	res, err = stmt.QueryMulti()
+	utest.IsNilNow(t, err)
+
+	table, ok = res.NextResult()
+	utest.EqualNow(t, ok, true)
+	utest.EqualNow(t, len(table.Fields()), 1)
+	utest.EqualNow(t, len(table.Rows()), 4)
+
+	table, ok = res.NextResult()
+	utest.EqualNow(t, ok, true)
+	utest.EqualNow(t, len(table.Fields()), 2)
+	utest.EqualNow(t, len(table.Rows()), 1)
+	utest.EqualNow(t, table.Rows()[0][1].String(), "4")
+
+	// the status result of CALL
+	table, ok = res.NextResult()
+	utest.EqualNow(t, ok, true)
+	utest.EqualNow(t, len(table.Fields()), 0)
+
+	_, ok = res.NextResult()
+	utest.Assert(t, !ok)
+	utest.IsNilNow(t, res.Err())
+	res.Close()
+
+	_, err = conn.Execute("DROP PROCEDURE test_multi")
+	utest.IsNilNow(t, err)
+}
+
+func Test_Time(t *testing.T) {
+	conn, err := Connect(TestConnParam)
+	utest.IsNilNow(t, err)
+	defer conn.Close()
+
+	_, err = conn.Execute("SET SESSION sql_mode = ''")
+	utest.IsNilNow(t, err)
+
+	_, err = conn.Execute(`CREATE TABLE test_time (
+		id INT PRIMARY KEY,
+		d DATE,
+		dt DATETIME(6),
+		ts TIMESTAMP NULL,
+		tm TIME(3)
+	)`)
+	utest.IsNilNow(t, err)
+	defer conn.Execute("DROP TABLE test_time")
+
+	_, err = conn.Execute(`INSERT INTO test_time VALUES
+		(1, '2015-07-08', '2015-07-08 09:10:11.123456', '2015-07-08 09:10:11', '-123:45:56.789'),
+		(2, '0000-00-00', '0000-00-00 00:00:00', NULL, '00:00:00')`)
+	utest.IsNilNow(t, err)
+
+	check := func(rows [][]Value) {
+		utest.EqualNow(t, len(rows), 2)
+
+		d, err := rows[0][1].Time(time.UTC)
+		utest.IsNilNow(t, err)
+		utest.Assert(t, d.Equal(time.Date(2015, 7, 8, 0, 0, 0, 0, time.UTC)))
+
+		dt, err := rows[0][2].Time(time.Local)
+		utest.IsNilNow(t, err)
+		utest.Assert(t, dt.Equal(time.Date(2015, 7, 8, 9, 10, 11, 123456000, time.Local)))
+
+		ts, ok := rows[0][3].Interface().(time.Time)
+		utest.Assert(t, ok)
+		utest.Assert(t, ts.Equal(time.Date(2015, 7, 8, 9, 10, 11, 0, time.UTC)))
+
+		tm, err := rows[0][4].Duration()
+		utest.IsNilNow(t, err)
+		utest.EqualNow(t, tm, -(123*time.Hour + 45*time.Minute + 56*time.Second + 789*time.Millisecond))
+		utest.EqualNow(t, rows[0][4].Interface(), tm)
+		utest.EqualNow(t, rows[1][4].Interface(), time.Duration(0))
+
+		utest.EqualNow(t, rows[0][1].String(), "2015-07-08")
+		utest.EqualNow(t, rows[0][2].String(), "2015-07-08 09:10:11.123456")
+
+		_, err = rows[1][3].Time(nil)
+		utest.Assert(t, errors.Is(err, ErrNullValue))
+
+		zero, err := rows[1][1].Time(nil)
+		utest.IsNilNow(t, err)
+		utest.Assert(t, zero.IsZero())
+
+		zero, err = rows[1][2].Time(nil)
+		utest.IsNilNow(t, err)
+		utest.Assert(t, zero.IsZero())
+
+		_, err = rows[1][0].Time(nil)
+		utest.Assert(t, err != nil)
+	}
+
+	table, err := conn.QueryTable("SELECT * FROM test_time ORDER BY id")
+	utest.IsNilNow(t, err)
+	check(table.Rows())
+
+	stmt, err := conn.Prepare("SELECT * FROM test_time ORDER BY id")
+	utest.IsNilNow(t, err)
+	defer stmt.Close()
+
+	table, err = stmt.QueryTable()
+	utest.IsNilNow(t, err)
+	check(table.Rows())
+}
+
+func Test_BindTypes(t *testing.T) {
+	conn, err := Connect(TestConnParam)
+	utest.IsNilNow(t, err)
+	defer conn.Close()
+
+	_, err = conn.Execute(`CREATE TABLE test_bind (
+		dt DATETIME(6),
+		tm TIME,
+		b  TINYINT,
+		u  BIGINT UNSIGNED,
+		n  INT,
+		s  VARCHAR(10),
+		p  INT
+	)`)
+	utest.IsNilNow(t, err)
+	defer conn.Execute("DROP TABLE test_bind")
+
+	stmt, err := conn.Prepare("INSERT INTO test_bind VALUES(?, ?, ?, ?, ?, ?, ?)")
+	utest.IsNilNow(t, err)
+	defer stmt.Close()
+
+	now := time.Date(2015, 7, 8, 9, 10, 11, 123456000, time.UTC)
+
+	utest.IsNilNow(t, stmt.Bind(now))
+	utest.IsNilNow(t, stmt.Bind(-(25*time.Hour + 30*time.Second)))
+	utest.IsNilNow(t, stmt.Bind(true))
+	utest.IsNilNow(t, stmt.Bind(uint64(1<<63+1)))
+	utest.IsNilNow(t, stmt.Bind(nil))
+	utest.IsNilNow(t, stmt.Bind(NullString{"abc", true}))
+	utest.IsNilNow(t, stmt.Bind((*int)(nil)))
+
+	// too many parameters
+	utest.Assert(t, stmt.Bind(1) != nil)
+
+	_, err = stmt.Execute()
+	utest.IsNilNow(t, err)
+
+	table, err := conn.QueryTable("SELECT * FROM test_bind")
+	utest.IsNilNow(t, err)
+	utest.EqualNow(t, len(table.Rows()), 1)
+
+	row := table.Rows()[0]
+	utest.EqualNow(t, row[0].String(), "2015-07-08 09:10:11.123456")
+	utest.EqualNow(t, row[1].String(), "-25:00:30")
+	utest.EqualNow(t, row[2].String(), "1")
+	utest.EqualNow(t, row[3].String(), "9223372036854775809")
+	utest.Assert(t, row[4].IsNull())
+	utest.EqualNow(t, row[5].String(), "abc")
+	utest.Assert(t, row[6].IsNull())
+
+	stmt.CleanBind()
+	utest.Assert(t, stmt.Bind(struct{}{}) != nil)
+}
+
+// a driver.Valuer for test
+type NullString struct {
+	String string
+	Valid  bool
+}
+
+func (ns NullString) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return ns.String, nil
+}
+
+func Test_Fields(t *testing.T) {
+	conn, err := Connect(TestConnParam)
+	utest.IsNilNow(t, err)
+	defer conn.Close()
+
+	_, err = conn.Execute(`CREATE TABLE test_fields (
+		id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+		name VARCHAR(20) NULL UNIQUE,
+		price DECIMAL(10, 2) ZEROFILL,
+		kind ENUM('a', 'b'),
+		data VARBINARY(10)
+	)`)
+	utest.IsNilNow(t, err)
+	defer conn.Execute("DROP TABLE test_fields")
+
+	check := func(fields []Field) {
+		utest.EqualNow(t, len(fields), 5)
+
+		id := fields[0]
+		utest.EqualNow(t, id.Name, "the_id")
+		utest.EqualNow(t, id.OrgName, "id")
+		utest.EqualNow(t, id.Table, "t")
+		utest.EqualNow(t, id.OrgTable, "test_fields")
+		utest.EqualNow(t, id.Database, TestConnParam.DbName)
+		utest.Assert(t, id.IsUnsigned())
+		utest.Assert(t, id.IsPrimaryKey())
+		utest.Assert(t, id.IsAutoIncrement())
+		utest.Assert(t, !id.Nullable())
+
+		name := fields[1]
+		utest.Assert(t, name.Nullable())
+		utest.Assert(t, name.IsUniqueKey())
+		utest.Assert(t, !name.IsBinary())
+
+		price := fields[2]
+		utest.EqualNow(t, price.Decimals, 2)
+		utest.Assert(t, price.IsZerofill())
+
+		utest.Assert(t, fields[3].IsEnum())
+		utest.Assert(t, fields[4].IsBinary())
+		utest.EqualNow(t, fields[4].Charset, 63)
+	}
+
+	sql := "SELECT id AS the_id, name, price, kind, data FROM test_fields AS t"
+
+	table, err := conn.QueryTable(sql)
+	utest.IsNilNow(t, err)
+	check(table.Fields())
+
+	stmt, err := conn.Prepare(sql)
+	utest.IsNilNow(t, err)
+	defer stmt.Close()
+
+	table, err = stmt.QueryTable()
+	utest.IsNilNow(t, err)
+	check(table.Fields())
+}
+
+func Test_Unsigned(t *testing.T) {
+	conn, err := Connect(TestConnParam)
+	utest.IsNilNow(t, err)
+	defer conn.Close()
+
+	_, err = conn.Execute(`CREATE TABLE test_unsigned (
+		t TINYINT UNSIGNED,
+		s SMALLINT UNSIGNED,
+		i INT UNSIGNED,
+		b BIGINT UNSIGNED
+	)`)
+	utest.IsNilNow(t, err)
+	defer conn.Execute("DROP TABLE test_unsigned")
+
+	_, err = conn.Execute("INSERT INTO test_unsigned VALUES(200, 60000, 4000000000, 18446744073709551615)")
+	utest.IsNilNow(t, err)
+
+	check := func(row []Value) {
+		utest.EqualNow(t, row[0].Uint8(), uint8(200))
+		utest.EqualNow(t, row[0].Int64(), int64(200))
+		utest.EqualNow(t, row[1].Uint16(), uint16(60000))
+		utest.EqualNow(t, row[2].Uint32(), uint32(4000000000))
+		utest.EqualNow(t, row[3].Uint64(), uint64(18446744073709551615))
+		utest.EqualNow(t, row[3].String(), "18446744073709551615")
+	}
+
+	table, err := conn.QueryTable("SELECT * FROM test_unsigned")
+	utest.IsNilNow(t, err)
+	check(table.Rows()[0])
+
+	stmt, err := conn.Prepare("SELECT * FROM test_unsigned")
+	utest.IsNilNow(t, err)
+	defer stmt.Close()
+
+	table, err = stmt.QueryTable()
+	utest.IsNilNow(t, err)
+	check(table.Rows()[0])
+	utest.EqualNow(t, table.Rows()[0][3].Interface(), uint64(18446744073709551615))
+}
+
+func Test_TryConvert(t *testing.T) {
+	conn, err := Connect(TestConnParam)
+	utest.IsNilNow(t, err)
+	defer conn.Close()
+
+	sql := "SELECT CAST(300 AS SIGNED), 'abc', CAST(NULL AS SIGNED), CAST(1.5 AS DECIMAL(4,2))"
+
+	check := func(row []Value) {
+		i16, err := row[0].TryInt16()
+		utest.IsNilNow(t, err)
+		utest.EqualNow(t, i16, int16(300))
+
+		_, err = row[0].TryInt8()
+		utest.Assert(t, errors.Is(err, strconv.ErrRange))
+
+		_, err = row[1].TryInt64()
+		utest.Assert(t, errors.Is(err, strconv.ErrSyntax))
+
+		var ce *ConvertError
+		utest.Assert(t, errors.As(err, &ce))
+		utest.EqualNow(t, ce.Target, "int64")
+
+		n, err := row[0].NullInt64()
+		utest.IsNilNow(t, err)
+		utest.Assert(t, n.Valid)
+		utest.EqualNow(t, n.Int64, int64(300))
+
+		f, err := row[3].TryFloat64()
+		utest.IsNilNow(t, err)
+		utest.EqualNow(t, f, 1.5)
+	}
+
+	table, err := conn.QueryTable(sql)
+	utest.IsNilNow(t, err)
+	check(table.Rows()[0])
+
+	null := table.Rows()[0][2]
+	_, err = null.TryInt64()
+	utest.Assert(t, errors.Is(err, ErrNullValue))
+
+	n, err := null.NullInt64()
+	utest.IsNilNow(t, err)
+	utest.Assert(t, !n.Valid)
+
+	stmt, err := conn.Prepare(sql)
+	utest.IsNilNow(t, err)
+	defer stmt.Close()
+
+	table, err = stmt.QueryTable()
+	utest.IsNilNow(t, err)
+	check(table.Rows()[0])
+}
+
+func Test_NullAndEmpty(t *testing.T) {
+	conn, err := Connect(TestConnParam)
+	utest.IsNilNow(t, err)
+	defer conn.Close()
+
+	_, err = conn.Execute(`CREATE TABLE test_null (
+		id INT PRIMARY KEY,
+		s VARCHAR(10),
+		b BLOB,
+		i INT
+	)`)
+	utest.IsNilNow(t, err)
+	defer conn.Execute("DROP TABLE test_null")
+
+	_, err = conn.Execute("INSERT INTO test_null VALUES(1, '', '', 0), (2, NULL, NULL, NULL), (3, 'a', 'b', 1)")
+	utest.IsNilNow(t, err)
+
+	check := func(rows [][]Value) {
+		utest.EqualNow(t, len(rows), 3)
+
+		for i := 1; i < 4; i++ {
+			utest.Assert(t, !rows[0][i].IsNull())
+			utest.Assert(t, rows[1][i].IsNull())
+			utest.Assert(t, !rows[2][i].IsNull())
+		}
+
+		utest.EqualNow(t, rows[0][1].String(), "")
+		utest.EqualNow(t, rows[0][3].Int64(), int64(0))
+		utest.EqualNow(t, rows[2][1].String(), "a")
+		utest.Assert(t, rows[1][3].Interface() == nil)
+
+		_, err := rows[1][3].TryInt64()
+		utest.Assert(t, errors.Is(err, ErrNullValue))
+	}
+
+	sql := "SELECT * FROM test_null ORDER BY id"
+
+	table, err := conn.QueryTable(sql)
+	utest.IsNilNow(t, err)
+	check(table.Rows())
+
+	stmt, err := conn.Prepare(sql)
+	utest.IsNilNow(t, err)
+	defer stmt.Close()
+
+	table, err = stmt.QueryTable()
+	utest.IsNilNow(t, err)
+	check(table.Rows())
+
+	reader, err := stmt.QueryReader()
+	utest.IsNilNow(t, err)
+	defer reader.Close()
+
+	var rows [][]Value
+	for {
+		row, err := reader.FetchNext()
+		utest.IsNilNow(t, err)
+		if row == nil {
+			break
+		}
+		rows = append(rows, row)
+	}
+	check(rows)
+}
+
 func Test_Null(t *testing.T) {
 	conn, err := Connect(TestConnParam)
 	utest.IsNilNow(t, err)
@@ -193,6 +706,875 @@ func Test_Null(t *testing.T) {
 	utest.Assert(t, rows2[0][0].IsNull())
 }
 
+func Test_Context(t *testing.T) {
+	conn, err := Connect(TestConnParam)
+	utest.IsNilNow(t, err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	begin := time.Now()
+	// a killed SLEEP() returns 1 without error, but the result must not be used
+	_, err = conn.QueryTableContext(ctx, "SELECT SLEEP(10)")
+	utest.Assert(t, err != nil)
+	utest.Assert(t, errors.Is(err, context.DeadlineExceeded))
+	utest.Assert(t, time.Since(begin) < 5*time.Second)
+
+	// connection still usable after the query killed
+	res, err := conn.QueryTableContext(context.Background(), "SELECT * FROM test")
+	utest.IsNilNow(t, err)
+	utest.Assert(t, len(res.Rows()) > 0)
+
+	stmt, err := conn.PrepareContext(context.Background(), "SELECT SLEEP(10)")
+	utest.IsNilNow(t, err)
+	defer stmt.Close()
+
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel2)
+
+	_, err = stmt.QueryTableContext(ctx2)
+	utest.Assert(t, err != nil)
+	utest.Assert(t, errors.Is(err, context.Canceled))
+}
+
+func Test_Pool(t *testing.T) {
+	pool := NewPool(TestConnParam, PoolSettings{MaxOpen: 2, MaxIdle: 1})
+	defer pool.Close()
+
+	conn1, err := pool.Get(context.Background())
+	utest.IsNilNow(t, err)
+
+	conn2, err := pool.Get(context.Background())
+	utest.IsNilNow(t, err)
+
+	stats := pool.Stats()
+	utest.EqualNow(t, stats.Open, 2)
+	utest.EqualNow(t, stats.InUse, 2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err = pool.Get(ctx)
+	utest.Assert(t, err == context.DeadlineExceeded)
+	utest.EqualNow(t, pool.Stats().WaitCount, int64(1))
+
+	pool.Put(conn1)
+	pool.Put(conn2)
+
+	stats = pool.Stats()
+	utest.EqualNow(t, stats.Open, 1)
+	utest.EqualNow(t, stats.Idle, 1)
+	utest.EqualNow(t, stats.MaxIdleClosed, int64(1))
+
+	conn3, err := pool.Get(context.Background())
+	utest.IsNilNow(t, err)
+	utest.Assert(t, conn3 == conn1 || conn3 == conn2)
+
+	// broken connection will be discarded
+	conn3.errno = 2013
+	pool.Put(conn3)
+
+	stats = pool.Stats()
+	utest.EqualNow(t, stats.Open, 0)
+	utest.EqualNow(t, stats.BrokenClosed, int64(1))
+	utest.Assert(t, conn3.IsClosed())
+
+	// put a connection twice has no effect
+	conn4, err := pool.Get(context.Background())
+	utest.IsNilNow(t, err)
+	utest.EqualNow(t, pool.Stats().InUse, 1)
+	pool.Put(conn4)
+	pool.Put(conn4)
+
+	stats = pool.Stats()
+	utest.EqualNow(t, stats.Idle, 1)
+	utest.EqualNow(t, stats.InUse, 0)
+
+	// the error number is cleared by the next successful call
+	conn4, err = pool.Get(context.Background())
+	utest.IsNilNow(t, err)
+	conn4.errno = 2013
+	_, err = conn4.Execute("SELECT 1")
+	utest.IsNilNow(t, err)
+	pool.Put(conn4)
+	utest.EqualNow(t, pool.Stats().Idle, 1)
+	utest.Assert(t, !conn4.IsClosed())
+
+	pool.Close()
+	_, err = pool.Get(context.Background())
+	utest.Assert(t, err == ErrPoolClosed)
+}
+
+func Test_PoolIdleTimeout(t *testing.T) {
+	pool := NewPool(TestConnParam, PoolSettings{IdleTimeout: 100 * time.Millisecond})
+	defer pool.Close()
+
+	conn, err := pool.Get(context.Background())
+	utest.IsNilNow(t, err)
+	pool.Put(conn)
+	utest.EqualNow(t, pool.Stats().Idle, 1)
+
+	// the cleaner runs every second at least
+	time.Sleep(1500 * time.Millisecond)
+
+	stats := pool.Stats()
+	utest.EqualNow(t, stats.Open, 0)
+	utest.EqualNow(t, stats.IdleTimeoutClosed, int64(1))
+	utest.Assert(t, conn.IsClosed())
+}
+
+func Test_DedicatedThread(t *testing.T) {
+	param := TestConnParam
+	param.DedicatedThread = true
+
+	conn, err := Connect(param)
+	utest.IsNilNow(t, err)
+	defer conn.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				res, err := conn.QueryTable("SELECT * FROM test WHERE id < 10")
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				utest.Equal(t, len(res.Rows()), 10)
+			}
+		}()
+	}
+	wg.Wait()
+
+	conn.Close()
+	utest.Assert(t, conn.IsClosed())
+
+	_, err = conn.Execute("SELECT 1")
+	utest.Assert(t, err != nil)
+}
+
+type ScanBase struct {
+	Id      int64
+	Created time.Time
+}
+
+type ScanUser struct {
+	ScanBase
+	UserName string `mysql:"name"`
+	Score    float64
+	Nickname *string
+	Ignored  string `mysql:"-"`
+}
+
+func Test_ScanStruct(t *testing.T) {
+	conn, err := Connect(TestConnParam)
+	utest.IsNilNow(t, err)
+	defer conn.Close()
+
+	_, err = conn.Execute(`CREATE TABLE test_scan (
+		id BIGINT PRIMARY KEY,
+		created DATETIME,
+		name VARCHAR(10),
+		score DOUBLE,
+		nickname VARCHAR(10),
+		extra INT
+	)`)
+	utest.IsNilNow(t, err)
+	defer conn.Execute("DROP TABLE test_scan")
+
+	_, err = conn.Execute("INSERT INTO test_scan VALUES(1, '2016-01-02 03:04:05', 'a', 1.5, 'aa', 0), (2, '2016-01-03 03:04:05', 'b', 2.5, NULL, 0)")
+	utest.IsNilNow(t, err)
+
+	check := func(users []ScanUser) {
+		utest.EqualNow(t, len(users), 2)
+		utest.EqualNow(t, users[0].Id, int64(1))
+		utest.EqualNow(t, users[0].Created, time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC))
+		utest.EqualNow(t, users[0].UserName, "a")
+		utest.EqualNow(t, users[0].Score, 1.5)
+		utest.Assert(t, users[0].Nickname != nil && *users[0].Nickname == "aa")
+		utest.EqualNow(t, users[1].Id, int64(2))
+		utest.Assert(t, users[1].Nickname == nil)
+	}
+
+	sql := "SELECT * FROM test_scan ORDER BY id"
+
+	table, err := conn.QueryTable(sql)
+	utest.IsNilNow(t, err)
+	var users []ScanUser
+	utest.IsNilNow(t, table.ScanAll(&users))
+	check(users)
+
+	stmt, err := conn.Prepare(sql)
+	utest.IsNilNow(t, err)
+	defer stmt.Close()
+
+	table, err = stmt.QueryTable()
+	utest.IsNilNow(t, err)
+	var pusers []*ScanUser
+	utest.IsNilNow(t, table.ScanAll(&pusers))
+	utest.EqualNow(t, len(pusers), 2)
+	check([]ScanUser{*pusers[0], *pusers[1]})
+
+	reader, err := stmt.QueryReader()
+	utest.IsNilNow(t, err)
+	defer reader.Close()
+
+	var user ScanUser
+	utest.Assert(t, reader.ScanStruct(&user) == ErrNoRow)
+
+	users = nil
+	for {
+		row, err := reader.FetchNext()
+		utest.IsNilNow(t, err)
+		if row == nil {
+			break
+		}
+		var user ScanUser
+		utest.IsNilNow(t, reader.ScanStruct(&user))
+		users = append(users, user)
+	}
+	check(users)
+}
+
+func Test_Scan(t *testing.T) {
+	conn, err := Connect(TestConnParam)
+	utest.IsNilNow(t, err)
+	defer conn.Close()
+
+	_, err = conn.Execute(`CREATE TABLE test_scan_row (
+		i INT,
+		u INT UNSIGNED,
+		f DOUBLE,
+		s VARCHAR(10),
+		b BLOB,
+		ok TINYINT,
+		t DATETIME,
+		n VARCHAR(10)
+	)`)
+	utest.IsNilNow(t, err)
+	defer conn.Execute("DROP TABLE test_scan_row")
+
+	_, err = conn.Execute("INSERT INTO test_scan_row VALUES(-1, 4294967295, 1.5, 'abc', 'xyz', 1, '2016-01-02 03:04:05', NULL)")
+	utest.IsNilNow(t, err)
+
+	check := func(row []Value, scan func(dest ...interface{}) error) {
+		var (
+			i  int32
+			u  uint32
+			f  float64
+			s  string
+			b  []byte
+			ok bool
+			tm time.Time
+			n  *string
+			ns sql.NullString
+		)
+		utest.IsNilNow(t, scan(&i, &u, &f, &s, &b, &ok, &tm, &n))
+		utest.EqualNow(t, i, int32(-1))
+		utest.EqualNow(t, u, uint32(4294967295))
+		utest.EqualNow(t, f, 1.5)
+		utest.EqualNow(t, s, "abc")
+		utest.EqualNow(t, string(b), "xyz")
+		utest.EqualNow(t, ok, true)
+		utest.EqualNow(t, tm, time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC))
+		utest.Assert(t, n == nil)
+
+		utest.IsNilNow(t, scan(&ns, &ns, &ns, &ns, &ns, &ns, &ns, &ns))
+		utest.EqualNow(t, ns.Valid, false)
+
+		var i8 int8
+		err := scan(&i, &i8, &f, &s, &b, &ok, &tm, &n)
+		utest.Assert(t, errors.Is(err, strconv.ErrRange))
+
+		err = scan(&i, &u, &f, &s, &b, &ok, &tm, &s)
+		utest.Assert(t, errors.Is(err, ErrNullValue))
+
+		err = scan(&i, &u)
+		utest.Assert(t, err != nil)
+
+		// time.Time is a fmt.Stringer, but int64 and string are not
+		var stringer fmt.Stringer
+		utest.IsNilNow(t, scan(&i, &u, &f, &s, &b, &ok, &stringer, &n))
+		utest.EqualNow(t, stringer, fmt.Stringer(time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)))
+		err = scan(&stringer, &u, &f, &s, &b, &ok, &tm, &n)
+		utest.Assert(t, errors.Is(err, ErrTypeMismatch))
+
+		utest.Assert(t, ScanRow(row, &i, &u, &f, &s, &b, &ok, &tm, &n) == nil)
+	}
+
+	sql := "SELECT * FROM test_scan_row"
+
+	reader, err := conn.QueryReader(sql)
+	utest.IsNilNow(t, err)
+	utest.Assert(t, reader.Scan() == ErrNoRow)
+	row, err := reader.FetchNext()
+	utest.IsNilNow(t, err)
+	check(row, reader.Scan)
+	reader.Close()
+
+	stmt, err := conn.Prepare(sql)
+	utest.IsNilNow(t, err)
+	defer stmt.Close()
+
+	reader, err = stmt.QueryReader()
+	utest.IsNilNow(t, err)
+	row, err = reader.FetchNext()
+	utest.IsNilNow(t, err)
+	check(row, reader.Scan)
+	reader.Close()
+
+	table, err := stmt.QueryTable()
+	utest.IsNilNow(t, err)
+	utest.EqualNow(t, len(table.Rows()), 1)
+	check(table.Rows()[0], func(dest ...interface{}) error {
+		return ScanRow(table.Rows()[0], dest...)
+	})
+}
+
+func Test_QueryHelpers(t *testing.T) {
+	conn, err := Connect(TestConnParam)
+	utest.IsNilNow(t, err)
+	defer conn.Close()
+
+	type Item struct {
+		Id    int64
+		Value string
+	}
+
+	items, err := QueryAll[Item](conn, "SELECT id, value FROM test WHERE id < 10 ORDER BY id")
+	utest.IsNilNow(t, err)
+	utest.EqualNow(t, len(items), 10)
+	utest.EqualNow(t, items[9].Id, int64(9))
+	utest.EqualNow(t, items[9].Value, "9")
+
+	pitems, err := QueryAll[*Item](conn, "SELECT id, value FROM test WHERE id < ? ORDER BY id", 5)
+	utest.IsNilNow(t, err)
+	utest.EqualNow(t, len(pitems), 5)
+	utest.EqualNow(t, pitems[4].Id, int64(4))
+
+	ids, err := QueryAll[int](conn, "SELECT id FROM test WHERE id < 3 ORDER BY id")
+	utest.IsNilNow(t, err)
+	utest.EqualNow(t, len(ids), 3)
+	utest.EqualNow(t, ids[2], 2)
+
+	value, err := QueryOne[string](conn, "SELECT value FROM test WHERE id = ?", 1)
+	utest.IsNilNow(t, err)
+	utest.EqualNow(t, value, "1")
+
+	_, err = QueryOne[Item](conn, "SELECT id, value FROM test WHERE id < 0")
+	utest.Assert(t, err == ErrNoRows)
+
+	n := 0
+	for item, err := range QueryIter[Item](conn, "SELECT id, value FROM test ORDER BY id") {
+		utest.IsNilNow(t, err)
+		utest.EqualNow(t, item.Id, int64(n))
+		n++
+		if n == 3 {
+			break
+		}
+	}
+	utest.EqualNow(t, n, 3)
+
+	// The reader is closed after break, the connection can be used again.
+	_, err = conn.Execute("SELECT 1")
+	utest.IsNilNow(t, err)
+
+	_, err = QueryAll[Item](conn, "SELECT * FROM not_exists")
+	utest.Assert(t, err != nil)
+}
+
+func Test_Tx(t *testing.T) {
+	conn, err := Connect(TestConnParam)
+	utest.IsNilNow(t, err)
+	defer conn.Close()
+
+	_, err = conn.Execute("CREATE TABLE test_tx (id INT PRIMARY KEY) ENGINE=InnoDB")
+	utest.IsNilNow(t, err)
+	defer conn.Execute("DROP TABLE test_tx")
+
+	count := func() int64 {
+		res, err := conn.QueryTable("SELECT COUNT(*) FROM test_tx")
+		utest.IsNilNow(t, err)
+		return res.Rows()[0][0].Int64()
+	}
+
+	tx, err := conn.Begin(TxOptions{Isolation: IsolationSerializable})
+	utest.IsNilNow(t, err)
+
+	res, err := tx.QueryTable("SELECT @@transaction_isolation")
+	if err != nil {
+		// MySQL before 5.7.20
+		res, err = tx.QueryTable("SELECT @@tx_isolation")
+	}
+	utest.IsNilNow(t, err)
+	utest.EqualNow(t, res.Rows()[0][0].String(), "SERIALIZABLE")
+
+	_, err = tx.Execute("INSERT INTO test_tx VALUES(1)")
+	utest.IsNilNow(t, err)
+	utest.IsNilNow(t, tx.Savepoint("sp1"))
+	_, err = tx.Execute("INSERT INTO test_tx VALUES(2)")
+	utest.IsNilNow(t, err)
+	utest.IsNilNow(t, tx.RollbackTo("sp1"))
+	utest.IsNilNow(t, tx.ReleaseSavepoint("sp1"))
+	utest.IsNilNow(t, tx.Commit())
+	utest.Assert(t, tx.Done())
+	utest.Assert(t, tx.Commit() == ErrTxDone)
+	utest.Assert(t, tx.Rollback() == ErrTxDone)
+	_, err = tx.Execute("SELECT 1")
+	utest.Assert(t, err == ErrTxDone)
+	utest.EqualNow(t, count(), int64(1))
+
+	tx, err = conn.Begin(TxOptions{ReadOnly: true, ConsistentSnapshot: true})
+	utest.IsNilNow(t, err)
+	_, err = tx.Execute("INSERT INTO test_tx VALUES(3)")
+	utest.Assert(t, err != nil)
+	utest.IsNilNow(t, tx.Rollback())
+
+	// can't begin another transaction before the active one finished
+	tx, err = conn.Begin(TxOptions{})
+	utest.IsNilNow(t, err)
+	_, err = conn.Begin(TxOptions{})
+	utest.Assert(t, err == ErrTxActive)
+	utest.IsNilNow(t, tx.Rollback())
+
+	fail := errors.New("fail")
+	err = conn.RunInTx(TxOptions{}, func(tx *Tx) error {
+		_, err := tx.Execute("INSERT INTO test_tx VALUES(4)")
+		utest.IsNilNow(t, err)
+		return fail
+	})
+	utest.Assert(t, err == fail)
+	utest.EqualNow(t, count(), int64(1))
+
+	func() {
+		defer func() {
+			utest.Assert(t, recover() == fail)
+		}()
+		conn.RunInTx(TxOptions{}, func(tx *Tx) error {
+			_, err := tx.Execute("INSERT INTO test_tx VALUES(5)")
+			utest.IsNilNow(t, err)
+			panic(fail)
+		})
+	}()
+	utest.EqualNow(t, count(), int64(1))
+
+	err = conn.RunInTx(TxOptions{}, func(tx *Tx) error {
+		_, err := tx.Execute("INSERT INTO test_tx VALUES(6)")
+		return err
+	})
+	utest.IsNilNow(t, err)
+	utest.EqualNow(t, count(), int64(2))
+}
+
+func Test_Retry(t *testing.T) {
+	conn, err := Connect(TestConnParam)
+	utest.IsNilNow(t, err)
+	defer conn.Close()
+
+	_, err = conn.Execute("CREATE TABLE test_retry (id INT PRIMARY KEY) ENGINE=InnoDB")
+	utest.IsNilNow(t, err)
+	defer conn.Execute("DROP TABLE test_retry")
+
+	retries := 0
+	policy := RetryPolicy{
+		BaseDelay: time.Millisecond,
+		OnRetry: func(attempt int, err error, delay time.Duration) {
+			retries++
+			// the delay doubles after each retry
+			max := time.Millisecond << uint(attempt-1)
+			utest.Assert(t, delay >= max/2 && delay <= max, delay)
+		},
+	}
+
+	n := 0
+	attempts, err := conn.RunInTxRetry(TxOptions{}, policy, func(tx *Tx) error {
+		n++
+		_, err := tx.Execute("INSERT INTO test_retry VALUES(1)")
+		utest.IsNilNow(t, err)
+		if n < 3 {
+			return &SqlError{MySQLError{Num: 1213, Message: "Deadlock found when trying to get lock"}}
+		}
+		return nil
+	})
+	utest.IsNilNow(t, err)
+	utest.EqualNow(t, attempts, 3)
+	utest.EqualNow(t, retries, 2)
+
+	res, err := conn.QueryTable("SELECT COUNT(*) FROM test_retry")
+	utest.IsNilNow(t, err)
+	utest.EqualNow(t, res.Rows()[0][0].Int64(), int64(1))
+
+	attempts, err = Retry(policy, func() error {
+		return &StmtError{MySQLError{Num: 1205, Message: "Lock wait timeout exceeded"}}
+	})
+	utest.Assert(t, err != nil)
+	utest.EqualNow(t, attempts, 3)
+
+	fail := errors.New("fail")
+	attempts, err = Retry(policy, func() error {
+		return fail
+	})
+	utest.Assert(t, err == fail)
+	utest.EqualNow(t, attempts, 1)
+}
+
+func Test_XA(t *testing.T) {
+	conn, err := Connect(TestConnParam)
+	utest.IsNilNow(t, err)
+	defer conn.Close()
+
+	_, err = conn.Execute("CREATE TABLE test_xa (id INT PRIMARY KEY) ENGINE=InnoDB")
+	utest.IsNilNow(t, err)
+	defer conn.Execute("DROP TABLE test_xa")
+
+	count := func() int64 {
+		res, err := conn.QueryTable("SELECT COUNT(*) FROM test_xa")
+		utest.IsNilNow(t, err)
+		return res.Rows()[0][0].Int64()
+	}
+
+	xid := XID{GTRID: "gtrid'1", BQUAL: "bqual\x001", FormatID: 7}
+
+	tx, err := conn.XAStart(xid)
+	utest.IsNilNow(t, err)
+	utest.EqualNow(t, tx.State(), XA_ACTIVE)
+	_, err = tx.Execute("INSERT INTO test_xa VALUES(1)")
+	utest.IsNilNow(t, err)
+
+	utest.Assert(t, errors.Is(tx.Commit(), ErrXAState))
+	utest.Assert(t, errors.Is(tx.Prepare(), ErrXAState))
+
+	utest.IsNilNow(t, tx.End())
+	utest.EqualNow(t, tx.State(), XA_IDLE)
+	_, err = tx.Execute("INSERT INTO test_xa VALUES(2)")
+	utest.Assert(t, errors.Is(err, ErrXAState))
+
+	utest.IsNilNow(t, tx.Prepare())
+	utest.EqualNow(t, tx.State(), XA_PREPARED)
+
+	xids, err := conn.XARecover()
+	utest.IsNilNow(t, err)
+	found := false
+	for _, x := range xids {
+		if x == xid {
+			found = true
+		}
+	}
+	utest.Assert(t, found)
+
+	utest.IsNilNow(t, tx.Commit())
+	utest.EqualNow(t, tx.State(), XA_COMMITTED)
+	utest.Assert(t, errors.Is(tx.Rollback(), ErrXAState))
+	utest.EqualNow(t, count(), int64(1))
+
+	xid.BQUAL = "bqual2"
+	tx, err = conn.XAStart(xid)
+	utest.IsNilNow(t, err)
+	_, err = tx.Execute("INSERT INTO test_xa VALUES(2)")
+	utest.IsNilNow(t, err)
+	utest.IsNilNow(t, tx.Rollback())
+	utest.EqualNow(t, tx.State(), XA_ROLLED_BACK)
+	utest.EqualNow(t, count(), int64(1))
+
+	xid.BQUAL = "bqual3"
+	tx, err = conn.XAStart(xid)
+	utest.IsNilNow(t, err)
+	_, err = tx.Execute("INSERT INTO test_xa VALUES(3)")
+	utest.IsNilNow(t, err)
+	utest.IsNilNow(t, tx.End())
+	utest.IsNilNow(t, tx.CommitOnePhase())
+	utest.EqualNow(t, count(), int64(2))
+
+	xid.BQUAL = "bqual4"
+	tx, err = conn.XAStart(xid)
+	utest.IsNilNow(t, err)
+	utest.IsNilNow(t, tx.End())
+	utest.IsNilNow(t, tx.Prepare())
+	utest.IsNilNow(t, conn.XARollback(xid))
+
+	utest.EqualNow(t, XID{GTRID: "g"}.String(), "X'67',X'',1")
+	_, err = conn.XAStart(XID{GTRID: string(make([]byte, 65))})
+	utest.Assert(t, err != nil)
+
+	// the branch rolled back by deadlock, XA END returns XA_RBDEADLOCK
+	_, err = conn.Execute("INSERT INTO test_xa VALUES(10),(11)")
+	utest.IsNilNow(t, err)
+
+	conn2, err := Connect(TestConnParam)
+	utest.IsNilNow(t, err)
+	defer conn2.Close()
+
+	tx1, err := conn.XAStart(XID{GTRID: "deadlock", BQUAL: "1"})
+	utest.IsNilNow(t, err)
+	tx2, err := conn2.XAStart(XID{GTRID: "deadlock", BQUAL: "2"})
+	utest.IsNilNow(t, err)
+	_, err = tx1.QueryTable("SELECT * FROM test_xa WHERE id = 10 FOR UPDATE")
+	utest.IsNilNow(t, err)
+	_, err = tx2.QueryTable("SELECT * FROM test_xa WHERE id = 11 FOR UPDATE")
+	utest.IsNilNow(t, err)
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := tx1.QueryTable("SELECT * FROM test_xa WHERE id = 11 FOR UPDATE")
+		errs <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	_, err2 := tx2.QueryTable("SELECT * FROM test_xa WHERE id = 10 FOR UPDATE")
+	err1 := <-errs
+
+	victim, other := tx2, tx1
+	if err1 != nil {
+		victim, other = tx1, tx2
+	}
+	utest.Assert(t, IsDeadlock(err1) != IsDeadlock(err2))
+	utest.Assert(t, IsDeadlock(victim.End()))
+	utest.EqualNow(t, victim.State(), XA_ROLLED_BACK)
+	utest.Assert(t, errors.Is(victim.Rollback(), ErrXAState))
+	utest.IsNilNow(t, other.Rollback())
+
+	_, err = victim.Conn().Execute("SELECT 1")
+	utest.IsNilNow(t, err)
+}
+
+func Test_ErrorClass(t *testing.T) {
+	conn, err := Connect(TestConnParam)
+	utest.IsNilNow(t, err)
+	defer conn.Close()
+
+	_, err = conn.Execute("INSERT INTO test VALUES(1, '1')")
+	utest.Assert(t, IsDuplicateKey(err))
+	utest.Assert(t, errors.Is(err, ErrDuplicateKey))
+	utest.Assert(t, !errors.Is(err, ErrDeadlock))
+	utest.Assert(t, !IsRetryable(err))
+	utest.EqualNow(t, err.(*SqlError).Num, ER_DUP_ENTRY)
+	utest.EqualNow(t, err.(*SqlError).SQLState, "23000")
+
+	stmt, err := conn.Prepare("INSERT INTO test VALUES(?, '1')")
+	utest.IsNilNow(t, err)
+	utest.IsNilNow(t, stmt.Bind(1))
+	_, err = stmt.Execute()
+	utest.Assert(t, IsDuplicateKey(err))
+	utest.EqualNow(t, err.(*StmtError).SQLState, "23000")
+	stmt.Close()
+
+	_, err = conn.Execute("SELECT * FROM not_exists")
+	utest.EqualNow(t, err.(*SqlError).Num, ER_NO_SUCH_TABLE)
+	utest.EqualNow(t, err.(*SqlError).SQLState, "42S02")
+
+	utest.Assert(t, IsDeadlock(&SqlError{MySQLError{Num: ER_LOCK_DEADLOCK}}))
+	utest.Assert(t, IsDeadlock(&SqlError{MySQLError{Num: ER_XA_RBDEADLOCK}}))
+	utest.Assert(t, IsLockWaitTimeout(&SqlError{MySQLError{Num: ER_XA_RBTIMEOUT}}))
+	utest.EqualNow(t, WARN_DATA_TRUNCATED, 1265)
+	utest.Assert(t, IsRetryable(&StmtError{MySQLError{Num: ER_LOCK_WAIT_TIMEOUT}}))
+	utest.Assert(t, IsReadOnly(&SqlError{MySQLError{Num: ER_CANT_EXECUTE_IN_READ_ONLY_TRANSACTION}}))
+	utest.Assert(t, !IsDeadlock(errors.New("deadlock")))
+
+	conn.Close()
+	_, err = conn.Execute("SELECT 1")
+	utest.Assert(t, IsConnectionLost(err))
+	utest.Assert(t, errors.Is(err, ErrConnectionLost))
+}
+
+func Test_Error(t *testing.T) {
+	conn, err := Connect(TestConnParam)
+	utest.IsNilNow(t, err)
+	defer conn.Close()
+
+	id := conn.Id()
+
+	_, err = conn.QueryTable("SELECT * FROM not_exists")
+	var se *SqlError
+	utest.Assert(t, errors.As(err, &se))
+	var me *MySQLError
+	utest.Assert(t, errors.As(err, &me))
+	utest.EqualNow(t, me.Num, ER_NO_SUCH_TABLE)
+	utest.EqualNow(t, me.Op, "query")
+	utest.EqualNow(t, me.Query, "SELECT * FROM not_exists")
+	utest.EqualNow(t, me.ConnId, id)
+
+	stmt, err := conn.Prepare("INSERT INTO test VALUES(?, '1')")
+	utest.IsNilNow(t, err)
+	utest.IsNilNow(t, stmt.Bind(1))
+	_, err = stmt.Execute()
+	var ste *StmtError
+	utest.Assert(t, errors.As(err, &ste))
+	utest.Assert(t, errors.As(err, &me))
+	utest.EqualNow(t, me.Num, ER_DUP_ENTRY)
+	utest.EqualNow(t, me.Op, "execute")
+	utest.EqualNow(t, me.Query, "INSERT INTO test VALUES(?, '1')")
+	utest.EqualNow(t, me.ConnId, id)
+
+	var ne NumberedError
+	utest.Assert(t, errors.As(err, &ne))
+	utest.EqualNow(t, ne.Number(), ER_DUP_ENTRY)
+
+	conn.Close()
+	_, err = stmt.Execute()
+	utest.Assert(t, errors.As(err, &ste))
+	utest.EqualNow(t, ste.Num, CR_SERVER_GONE_ERROR)
+	utest.Assert(t, IsConnectionLost(err))
+	stmt.Close()
+}
+
+func Test_Warnings(t *testing.T) {
+	conn, err := Connect(TestConnParam)
+	utest.IsNilNow(t, err)
+	defer conn.Close()
+
+	_, err = conn.Execute("CREATE TABLE test_warning (s VARCHAR(2))")
+	utest.IsNilNow(t, err)
+	defer conn.Execute("DROP TABLE test_warning")
+
+	// Disable strict SQL mode, so truncation is a warning.
+	_, err = conn.Execute("SET SESSION sql_mode = ''")
+	utest.IsNilNow(t, err)
+
+	res, err := conn.Execute("INSERT INTO test_warning VALUES('abc')")
+	utest.IsNilNow(t, err)
+	utest.EqualNow(t, res.WarningCount(), 1)
+
+	warnings, err := conn.Warnings()
+	utest.IsNilNow(t, err)
+	utest.EqualNow(t, len(warnings), 1)
+	utest.EqualNow(t, warnings[0].Level, "Warning")
+	utest.EqualNow(t, warnings[0].Code, WARN_DATA_TRUNCATED)
+
+	res, err = conn.Execute("INSERT INTO test_warning VALUES('a')")
+	utest.IsNilNow(t, err)
+	utest.EqualNow(t, res.WarningCount(), 0)
+
+	stmt, err := conn.Prepare("INSERT INTO test_warning VALUES(?)")
+	utest.IsNilNow(t, err)
+	defer stmt.Close()
+	utest.IsNilNow(t, stmt.Bind("abc"))
+	res, err = stmt.Execute()
+	utest.IsNilNow(t, err)
+	utest.EqualNow(t, res.WarningCount(), 1)
+
+	param := TestConnParam
+	param.StrictWarnings = true
+	strict, err := Connect(param)
+	utest.IsNilNow(t, err)
+	defer strict.Close()
+
+	_, err = strict.Execute("SET SESSION sql_mode = ''")
+	utest.IsNilNow(t, err)
+
+	// the statement has been executed, the result is returned with the error
+	res, err = strict.Execute("INSERT INTO test_warning VALUES('abc')")
+	var we *WarningError
+	utest.Assert(t, errors.As(err, &we))
+	utest.EqualNow(t, len(we.Warnings), 1)
+	utest.EqualNow(t, we.Warnings[0].Code, WARN_DATA_TRUNCATED)
+	utest.Assert(t, res != nil)
+	utest.EqualNow(t, res.RowsAffected(), 1)
+
+	// notes are not errors
+	_, err = strict.Execute("DROP TABLE IF EXISTS test_warning_not_exists")
+	utest.IsNilNow(t, err)
+
+	_, err = strict.QueryTable("SELECT CAST('1x' AS SIGNED)")
+	utest.Assert(t, errors.As(err, &we))
+
+	_, err = strict.Execute("INSERT INTO test_warning VALUES('a')")
+	utest.IsNilNow(t, err)
+}
+
+func Test_QueryMultiReader(t *testing.T) {
+	param := TestConnParam
+	param.Flags = CF_CLIENT_MULTI_STATEMENTS
+
+	conn, err := Connect(param)
+	utest.IsNilNow(t, err)
+	defer conn.Close()
+
+	res, err := conn.QueryMulti("SELECT id FROM test WHERE id < 3 ORDER BY id; UPDATE test SET value = value WHERE id < 5; SELECT 1, 2")
+	utest.IsNilNow(t, err)
+	utest.Assert(t, res.HasNext())
+
+	reader, ok := res.NextReader()
+	utest.EqualNow(t, ok, true)
+	utest.EqualNow(t, len(reader.Fields()), 1)
+	n := 0
+	for {
+		row, err := reader.FetchNext()
+		utest.IsNilNow(t, err)
+		if row == nil {
+			break
+		}
+		utest.EqualNow(t, row[0].Int64(), int64(n))
+		n++
+	}
+	utest.EqualNow(t, n, 3)
+	utest.Assert(t, res.HasNext())
+
+	// the unread rows are discarded when moving to the next result
+	prev := reader
+	reader, ok = res.NextReader()
+	utest.EqualNow(t, ok, true)
+	utest.EqualNow(t, len(reader.Fields()), 0)
+
+	// the previous reader is closed
+	prevRow, err := prev.FetchNext()
+	utest.IsNilNow(t, err)
+	utest.Assert(t, prevRow == nil)
+
+	reader, ok = res.NextReader()
+	utest.EqualNow(t, ok, true)
+	utest.EqualNow(t, len(reader.Fields()), 2)
+	row, err := reader.FetchNext()
+	utest.IsNilNow(t, err)
+	utest.EqualNow(t, row[1].Int64(), int64(2))
+	reader.Close()
+	utest.Assert(t, !res.HasNext())
+
+	_, ok = res.NextReader()
+	utest.Assert(t, !ok)
+	utest.IsNilNow(t, res.Err())
+	res.Close()
+
+	_, err = conn.Execute(`CREATE PROCEDURE test_multi_reader(IN n INT)
+	BEGIN
+		SELECT id FROM test WHERE id < n ORDER BY id;
+		SELECT id, value FROM test WHERE id = n;
+	END`)
+	utest.IsNilNow(t, err)
+
+	stmt, err := conn.Prepare("CALL test_multi_reader(?)")
+	utest.IsNilNow(t, err)
+
+	stmt.BindInt(4)
+	res, err = stmt.QueryMulti()
+	utest.IsNilNow(t, err)
+
+	reader, ok = res.NextReader()
+	utest.EqualNow(t, ok, true)
+	utest.EqualNow(t, len(reader.Fields()), 1)
+	row, err = reader.FetchNext()
+	utest.IsNilNow(t, err)
+	utest.EqualNow(t, row[0].Int64(), int64(0))
+
+	reader, ok = res.NextReader()
+	utest.EqualNow(t, ok, true)
+	utest.EqualNow(t, len(reader.Fields()), 2)
+	row, err = reader.FetchNext()
+	utest.IsNilNow(t, err)
+	utest.EqualNow(t, row[1].String(), "4")
+	res.Close()
+
+	// the connection is still usable
+	table, err := conn.QueryTable("SELECT 1")
+	utest.IsNilNow(t, err)
+	utest.EqualNow(t, len(table.Rows()), 1)
+
+	stmt.Close()
+	_, err = conn.Execute("DROP PROCEDURE test_multi_reader")
+	utest.IsNilNow(t, err)
+}
+
 func Test_Clean(t *testing.T) {
 	conn, err := Connect(TestConnParam)
 	utest.IsNilNow(t, err)
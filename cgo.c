@@ -5,6 +5,8 @@
 // All functions must call mysql_thread_init before calling mysql. This is
 // because the go runtime controls thread creation, and we don't control
 // which thread these functions will be called from.
+// Connections in dedicated thread mode call my_worker_init and my_worker_end
+// on their own locked thread, see worker.go.
 
 // this macro produces a compilation-time check for a condition
 // if the condition is different than zero, this will abort
@@ -19,6 +21,14 @@ void my_library_init(void) {
 	mysql_library_init(0, 0, 0);
 }
 
+void my_worker_init(void) {
+	mysql_thread_init();
+}
+
+void my_worker_end(void) {
+	mysql_thread_end();
+}
+
 int my_open(
     MYSQL         *mysql,
     const char    *host,
@@ -27,12 +37,28 @@ int my_open(
     const char    *db,
     unsigned int  port,
     const char    *unix_socket,
-    unsigned long client_flag
+    unsigned long client_flag,
+    unsigned int  connect_timeout,
+    unsigned int  ssl_mode
 ) {
 	mysql_thread_init();
 
 	mysql_init(mysql);
 
+	if (connect_timeout > 0) {
+		mysql_options(mysql, MYSQL_OPT_CONNECT_TIMEOUT, &connect_timeout);
+	}
+
+	if (ssl_mode > 0) {
+#if defined(MYSQL_VERSION_ID) && MYSQL_VERSION_ID >= 50711 && !defined(MARIADB_BASE_VERSION)
+		if (mysql_options(mysql, MYSQL_OPT_SSL_MODE, &ssl_mode) != 0) {
+			return 2;
+		}
+#else
+		return 2;
+#endif
+	}
+
 	if (!mysql_real_connect(mysql, host, user, passwd, db, port, unix_socket, client_flag)) {
 		return 1;
 	}
@@ -59,6 +85,11 @@ const char *my_error(MYSQL *mysql) {
 	return mysql_error(mysql);
 }
 
+const char *my_sqlstate(MYSQL *mysql) {
+	mysql_thread_init();
+	return mysql_sqlstate(mysql);
+}
+
 int my_autocommit(MYSQL *mysql, my_bool mode) {
 	mysql_thread_init();
 	return mysql_autocommit(mysql, mode);
@@ -74,6 +105,11 @@ int my_rollback(MYSQL *mysql) {
 	return mysql_rollback(mysql);
 }
 
+int my_ping(MYSQL *mysql) {
+	mysql_thread_init();
+	return mysql_ping(mysql);
+}
+
 unsigned long my_real_escape_string(MYSQL *mysql, char *to, const char *from, unsigned long length) {
 	mysql_thread_init();
 	return mysql_real_escape_string(mysql, to, from, length);
@@ -103,13 +139,15 @@ int my_query(MYSQL *mysql, MY_RES *res, const char *sql_str, unsigned long sql_l
 
 	res->affected_rows = mysql_affected_rows(mysql);
 	res->insert_id = mysql_insert_id(mysql);
+	res->warning_count = mysql_warning_count(mysql);
 	return 0;
 }
 
 MY_ROW my_fetch_next(MYSQL *mysql, MY_RES *res) {
 	MY_ROW row = {0, 0, 0};
 
-	if(res->meta.num_fields == 0) {
+	// No result set, or the result has been freed.
+	if(res->meta.num_fields == 0 || res->result == NULL) {
 		return row;
 	}
 
@@ -121,6 +159,8 @@ MY_ROW my_fetch_next(MYSQL *mysql, MY_RES *res) {
 			row.has_error = 1;
 			return row;
 		}
+		// The warnings of unbuffered result are known after all rows fetched.
+		res->warning_count = mysql_warning_count(mysql);
 	} else {
 		row.lengths = mysql_fetch_lengths(res->result);
 	}
@@ -135,6 +175,7 @@ void my_close_result(MYSQL *mysql, MY_RES *res) {
 
 	if (res->result) {
 		mysql_free_result(res->result);
+		res->result = NULL;
 	}
 
 	// Ignore subsequent results if any. We only
@@ -149,12 +190,64 @@ void my_close_result(MYSQL *mysql, MY_RES *res) {
 	}
 }
 
+int my_query_multi(MYSQL *mysql, const char *sql_str, unsigned long sql_len) {
+	mysql_thread_init();
+
+	if (mysql_real_query(mysql, sql_str, sql_len) != 0) {
+		return 1;
+	}
+	return 0;
+}
+
+int my_store_result(MYSQL *mysql, MY_RES *res, MY_MODE mode) {
+	mysql_thread_init();
+
+	if (mode == MY_MODE_READER) {
+		res->result = mysql_use_result(mysql);
+	} else {
+		res->result = mysql_store_result(mysql);
+	}
+	if (res->result == NULL) {
+		// No result set (e.g. INSERT), unless the field count says it should have one.
+		if (mysql_field_count(mysql) != 0) {
+			return 1;
+		}
+		res->meta.num_fields = 0;
+		res->meta.fields = NULL;
+	} else {
+		res->meta.num_fields = mysql_num_fields(res->result);
+		res->meta.fields =  mysql_fetch_fields(res->result);
+	}
+
+	res->affected_rows = mysql_affected_rows(mysql);
+	res->insert_id = mysql_insert_id(mysql);
+	res->warning_count = mysql_warning_count(mysql);
+	return 0;
+}
+
+int my_next_result(MYSQL *mysql) {
+	mysql_thread_init();
+	return mysql_next_result(mysql);
+}
+
+int my_more_results(MYSQL *mysql) {
+	return mysql_more_results(mysql);
+}
+
+void my_free_result(MY_RES *res) {
+	if (res->result) {
+		mysql_free_result(res->result);
+		res->result = NULL;
+	}
+}
+
 int my_prepare(MY_STMT **stmt, MYSQL_BIND **binds, MYSQL *mysql, const char *sql_str, unsigned long sql_len) {
 	mysql_thread_init();
 
 	*stmt = (MY_STMT*)calloc(1, sizeof(MY_STMT));
 	MY_STMT* s = *stmt;
 	s->s = mysql_stmt_init(mysql);
+	s->mysql = mysql;
 
 	if (mysql_stmt_prepare(s->s, sql_str, sql_len) != 0) {
 		return 1;
@@ -163,6 +256,49 @@ int my_prepare(MY_STMT **stmt, MYSQL_BIND **binds, MYSQL *mysql, const char *sql
 	s->param_count = mysql_stmt_param_count(s->s);
 	
 	*binds = (MYSQL_BIND*)calloc(s->param_count, sizeof(MYSQL_BIND));
+	s->param_cache = calloc(s->param_count, sizeof(char*));
+	s->param_cache_len = calloc(s->param_count, sizeof(size_t));
+	s->param_lengths = calloc(s->param_count, sizeof(unsigned long));
+	s->param_is_nulls = calloc(s->param_count, sizeof(my_bool));
+	return 0;
+}
+
+int my_stmt_bind(MY_STMT *stmt, MYSQL_BIND *binds, unsigned long pos, enum enum_field_types type, const void *data, unsigned long length, my_bool is_null, my_bool is_unsigned) {
+	if (pos >= stmt->param_count) {
+		return 1;
+	}
+
+	if (!is_null) {
+		// Always keep a valid buffer, even for empty string.
+		size_t size = length > 0 ? length : 1;
+		if (stmt->param_cache[pos] == NULL || stmt->param_cache_len[pos] < size) {
+			if (stmt->param_cache[pos] != NULL) {
+				free(stmt->param_cache[pos]);
+			}
+			stmt->param_cache[pos] = malloc(size);
+			if (stmt->param_cache[pos] == NULL) {
+				stmt->param_cache_len[pos] = 0;
+				return 1;
+			}
+			stmt->param_cache_len[pos] = size;
+		}
+		if (length > 0) {
+			memcpy(stmt->param_cache[pos], data, length);
+		}
+	}
+
+	MYSQL_BIND *b = &binds[pos];
+	memset(b, 0, sizeof(MYSQL_BIND));
+
+	stmt->param_lengths[pos] = is_null ? 0 : length;
+	stmt->param_is_nulls[pos] = is_null;
+
+	b->buffer_type = type;
+	b->buffer = is_null ? NULL : stmt->param_cache[pos];
+	b->buffer_length = is_null ? 0 : length;
+	b->length = &stmt->param_lengths[pos];
+	b->is_null = &stmt->param_is_nulls[pos];
+	b->is_unsigned = is_unsigned;
 	return 0;
 }
 
@@ -176,6 +312,120 @@ const char *my_stmt_error(MY_STMT *stmt) {
   return mysql_stmt_error(stmt->s);
 }
 
+const char *my_stmt_sqlstate(MY_STMT *stmt) {
+	mysql_thread_init();
+	return mysql_stmt_sqlstate(stmt->s);
+}
+
+// Free the output buffers. They are sized by stmt->meta, so call this before stmt->meta changed.
+static void my_stmt_free_outputs(MY_STMT *stmt) {
+	if (stmt->row_cache != NULL) {
+		for (int i = 0; i < stmt->meta.num_fields; i ++) {
+			if (stmt->row_cache[i] != NULL) {
+				free(stmt->row_cache[i]);
+			}
+		}
+		free(stmt->row_cache);
+		free(stmt->row_cache_len);
+		stmt->row_cache = NULL;
+		stmt->row_cache_len = NULL;
+	}
+
+	if (stmt->outputs != NULL) {
+		free(stmt->outputs);
+		free(stmt->output_lengths);
+		free(stmt->output_is_nulls);
+		stmt->outputs = NULL;
+		stmt->output_lengths = NULL;
+		stmt->output_is_nulls = NULL;
+	}
+}
+
+static int my_stmt_init_meta(MY_STMT *stmt) {
+	my_stmt_free_outputs(stmt);
+
+	MYSQL_RES *meta = mysql_stmt_result_metadata(stmt->s);
+	if (meta == NULL) {
+		return 1;
+	}
+	stmt->meta.num_fields = mysql_num_fields(meta);
+	stmt->meta.fields =  mysql_fetch_fields(meta);
+	mysql_free_result(meta);
+
+	my_bool enable = 1;
+	if (mysql_stmt_attr_set(stmt->s, STMT_ATTR_UPDATE_MAX_LENGTH, (void*)&enable) != 0) {
+		return 1;
+	}
+	return 0;
+}
+
+static int my_stmt_bind_outputs(MY_STMT *stmt) {
+	if (stmt->row_cache == NULL) {
+		stmt->row_cache = calloc(sizeof(char*), stmt->meta.num_fields);
+		stmt->row_cache_len = calloc(sizeof(size_t), stmt->meta.num_fields);
+	}
+
+	if (stmt->outputs == NULL) {
+		stmt->outputs = calloc(sizeof(MYSQL_BIND), stmt->meta.num_fields);
+		stmt->output_lengths = calloc(sizeof(unsigned long), stmt->meta.num_fields);
+		stmt->output_is_nulls = calloc(sizeof(my_bool), stmt->meta.num_fields);
+	} else {
+		memset(stmt->outputs, 0, sizeof(MYSQL_BIND) * stmt->meta.num_fields);
+		memset(stmt->output_lengths, 0, sizeof(unsigned long) * stmt->meta.num_fields);
+		memset(stmt->output_is_nulls, 0, sizeof(my_bool) * stmt->meta.num_fields);
+	}
+
+	for (int i = 0; i < stmt->meta.num_fields; i ++) {
+		size_t size = 0;
+
+		switch (stmt->meta.fields[i].type) {
+			case MYSQL_TYPE_TINY:      size = sizeof(signed char); break;   // TINYINT
+			case MYSQL_TYPE_SHORT:     size = sizeof(short int); break;     // SMALLINT
+			case MYSQL_TYPE_INT24:     size = sizeof(int); break;           // MEDIUMINT
+			case MYSQL_TYPE_LONG:      size = sizeof(int); break;           // INT
+			case MYSQL_TYPE_LONGLONG:  size = sizeof(long long int); break; // BIGINT
+			case MYSQL_TYPE_FLOAT:     size = sizeof(float); break;         // FLOAT
+			case MYSQL_TYPE_DOUBLE:    size = sizeof(double); break;        // DOUBLE
+			case MYSQL_TYPE_YEAR:      size = sizeof(short int); break;     // YEAR
+			case MYSQL_TYPE_TIME:      size = sizeof(MYSQL_TIME); break;    // TIME
+			case MYSQL_TYPE_DATE:      size = sizeof(MYSQL_TIME); break;    // DATE
+			case MYSQL_TYPE_DATETIME:  size = sizeof(MYSQL_TIME); break;    // DATETIME
+			case MYSQL_TYPE_TIMESTAMP: size = sizeof(MYSQL_TIME); break;    // TIMESTAMP
+
+			// Those type are dynamic length, see my_stmt_fetch_next()
+			case MYSQL_TYPE_DECIMAL:     // DECIMAL
+			case MYSQL_TYPE_NEWDATE:     // MYSQL_TYPE_NEWDATE
+			case MYSQL_TYPE_NEWDECIMAL:  // DECIMAL
+			case MYSQL_TYPE_STRING:      // CHAR, BINARY
+			case MYSQL_TYPE_VAR_STRING:  // VARCHAR, VARBINARY
+			case MYSQL_TYPE_TINY_BLOB:   // TINYBLOB, TINYTEXT
+			case MYSQL_TYPE_BLOB:        // BLOB, TEXT
+			case MYSQL_TYPE_MEDIUM_BLOB: // MEDIUMBLOB, MEDIUMTEXT
+			case MYSQL_TYPE_LONG_BLOB:   // LONGBLOB, LONGTEXT
+			case MYSQL_TYPE_BIT:         // BIT
+			
+			default: break;
+		}
+
+		if (size != 0 && stmt->row_cache[i] == NULL) {
+			stmt->row_cache[i] = malloc(size);
+			stmt->row_cache_len[i] = size;
+		}
+
+		stmt->outputs[i].buffer = size != 0 ? stmt->row_cache[i] : NULL;
+		stmt->outputs[i].buffer_length = size;
+		stmt->outputs[i].buffer_type = stmt->meta.fields[i].type;
+		stmt->outputs[i].is_unsigned = (stmt->meta.fields[i].flags & UNSIGNED_FLAG) != 0;
+		stmt->outputs[i].length = &(stmt->output_lengths[i]);
+		stmt->outputs[i].is_null = &(stmt->output_is_nulls[i]);
+	}
+
+	if (mysql_stmt_bind_result(stmt->s, stmt->outputs) != 0) {
+		return 1;
+	}
+	return 0;
+}
+
 int my_stmt_execute(MY_STMT *stmt, MYSQL_BIND *binds, MY_STMT_RES *res, MY_MODE mode) {
 	mysql_thread_init();
 
@@ -188,16 +438,7 @@ int my_stmt_execute(MY_STMT *stmt, MYSQL_BIND *binds, MY_STMT_RES *res, MY_MODE
 	if (mode != MY_MODE_NONE && stmt->meta_init == 0) {
 		stmt->meta_init = 1;
 
-		MYSQL_RES *meta = mysql_stmt_result_metadata(stmt->s);
-		if (meta == NULL) {
-			return 1;
-		}
-		stmt->meta.num_fields = mysql_num_fields(meta);
-		stmt->meta.fields =  mysql_fetch_fields(meta);
-		mysql_free_result(meta);
-
-		my_bool enable = 1;
-		if (mysql_stmt_attr_set(stmt->s, STMT_ATTR_UPDATE_MAX_LENGTH, (void*)&enable) != 0) {
+		if (my_stmt_init_meta(stmt) != 0) {
 			return 1;
 		}
 	}
@@ -213,89 +454,81 @@ int my_stmt_execute(MY_STMT *stmt, MYSQL_BIND *binds, MY_STMT_RES *res, MY_MODE
 			}
 		}
 
-		if (stmt->row_cache == NULL) {
-			stmt->row_cache = calloc(sizeof(char*), stmt->meta.num_fields);
-			stmt->row_cache_len = calloc(sizeof(size_t), stmt->meta.num_fields);
+		if (my_stmt_bind_outputs(stmt) != 0) {
+			return 1;
 		}
+	}
 
-		if (stmt->outputs == NULL) {
-			stmt->outputs = calloc(sizeof(MYSQL_BIND), stmt->meta.num_fields);
-			stmt->output_lengths = calloc(sizeof(unsigned long), stmt->meta.num_fields);
-		} else {
-			memset(stmt->outputs, 0, sizeof(MYSQL_BIND) * stmt->meta.num_fields);
-			memset(stmt->output_lengths, 0, sizeof(unsigned long) * stmt->meta.num_fields);
+	res->affected_rows = mysql_stmt_affected_rows(stmt->s);
+	res->insert_id = mysql_stmt_insert_id(stmt->s);
+	res->warning_count = mysql_warning_count(stmt->mysql);
+	return 0;
+}
+
+int my_stmt_execute_multi(MY_STMT *stmt, MYSQL_BIND *binds) {
+	mysql_thread_init();
+
+	if (binds != NULL) {
+		if (mysql_stmt_bind_param(stmt->s, binds) != 0) {
+			return 1;
 		}
+	}
 
-		for (int i = 0; i < stmt->meta.num_fields; i ++) {
-			size_t size = 0;
-
-			switch (stmt->meta.fields[i].type) {
-				case MYSQL_TYPE_TINY:      size = sizeof(signed char); break;   // TINYINT
-				case MYSQL_TYPE_SHORT:     size = sizeof(short int); break;     // SMALLINT
-				case MYSQL_TYPE_INT24:     size = sizeof(int); break;           // MEDIUMINT
-				case MYSQL_TYPE_LONG:      size = sizeof(int); break;           // INT
-				case MYSQL_TYPE_LONGLONG:  size = sizeof(long long int); break; // BIGINT
-				case MYSQL_TYPE_FLOAT:     size = sizeof(float); break;         // FLOAT
-				case MYSQL_TYPE_DOUBLE:    size = sizeof(double); break;        // DOUBLE
-				case MYSQL_TYPE_YEAR:      size = sizeof(short int); break;     // YEAR
-				case MYSQL_TYPE_TIME:      size = sizeof(MYSQL_TIME); break;    // TIME
-				case MYSQL_TYPE_DATE:      size = sizeof(MYSQL_TIME); break;    // DATE
-				case MYSQL_TYPE_DATETIME:  size = sizeof(MYSQL_TIME); break;    // DATETIME
-				case MYSQL_TYPE_TIMESTAMP: size = sizeof(MYSQL_TIME); break;    // TIMESTAMP
-
-				// Those type are dynamic length, see my_stmt_fetch_next()
-				case MYSQL_TYPE_DECIMAL:     // DECIMAL
-				case MYSQL_TYPE_NEWDATE:     // MYSQL_TYPE_NEWDATE
-				case MYSQL_TYPE_NEWDECIMAL:  // DECIMAL
-				case MYSQL_TYPE_STRING:      // CHAR, BINARY
-				case MYSQL_TYPE_VAR_STRING:  // VARCHAR, VARBINARY
-				case MYSQL_TYPE_TINY_BLOB:   // TINYBLOB, TINYTEXT
-				case MYSQL_TYPE_BLOB:        // BLOB, TEXT
-				case MYSQL_TYPE_MEDIUM_BLOB: // MEDIUMBLOB, MEDIUMTEXT
-				case MYSQL_TYPE_LONG_BLOB:   // LONGBLOB, LONGTEXT
-				case MYSQL_TYPE_BIT:         // BIT
-				
-				default: break;
-			}
+	if (mysql_stmt_execute(stmt->s) != 0) {
+		return 1;
+	}
+	return 0;
+}
 
-			if (size != 0 && stmt->row_cache[i] == NULL) {
-				stmt->row_cache[i] = malloc(size);
-				stmt->row_cache_len[i] = size;
-			}
+int my_stmt_store_result(MY_STMT *stmt, MY_STMT_RES *res, MY_MODE mode) {
+	mysql_thread_init();
 
-			stmt->outputs[i].buffer = size != 0 ? stmt->row_cache[i] : NULL;
-			stmt->outputs[i].buffer_length = size;
-			stmt->outputs[i].buffer_type = stmt->meta.fields[i].type;
-			stmt->outputs[i].length = &(stmt->output_lengths[i]);
-		}
+	// Every result has its own metadata, my_stmt_execute must re-init it next time.
+	stmt->meta_init = 0;
+	my_stmt_free_outputs(stmt);
+	stmt->meta.num_fields = 0;
+	stmt->meta.fields = NULL;
 
-		if (mysql_stmt_bind_result(stmt->s, stmt->outputs) != 0) {
+	if (mysql_stmt_field_count(stmt->s) != 0) {
+		if (my_stmt_init_meta(stmt) != 0) {
+			return 1;
+		}
+		if (mode != MY_MODE_READER && mysql_stmt_store_result(stmt->s) != 0) {
+			return 1;
+		}
+		if (my_stmt_bind_outputs(stmt) != 0) {
 			return 1;
 		}
 	}
 
 	res->affected_rows = mysql_stmt_affected_rows(stmt->s);
 	res->insert_id = mysql_stmt_insert_id(stmt->s);
+	res->warning_count = mysql_warning_count(stmt->mysql);
 	return 0;
 }
 
+int my_stmt_next_result(MY_STMT *stmt) {
+	mysql_thread_init();
+	return mysql_stmt_next_result(stmt->s);
+}
+
 int my_stmt_close(MY_STMT *stmt, MYSQL_BIND *binds) {
 	mysql_thread_init();
 
-	if (stmt->row_cache != NULL) {
-		for (int i = 0; i < stmt->meta.num_fields; i ++) {
-			if (stmt->row_cache[i] != NULL) {
-				free(stmt->row_cache[i]);
+	if (stmt->param_cache != NULL) {
+		for (int i = 0; i < stmt->param_count; i ++) {
+			if (stmt->param_cache[i] != NULL) {
+				free(stmt->param_cache[i]);
 			}
 		}
-		free(stmt->row_cache);
-		free(stmt->row_cache_len);
+		free(stmt->param_cache);
+		free(stmt->param_cache_len);
+		free(stmt->param_lengths);
+		free(stmt->param_is_nulls);
+		stmt->param_cache = NULL;
 	}
 
-	if (stmt->outputs != NULL) {
-		free(stmt->outputs);
-		free(stmt->output_lengths);
-	}
+	my_stmt_free_outputs(stmt);
 
 	if (mysql_stmt_close(stmt->s) != 0) {
 		return 1;
@@ -321,12 +554,15 @@ MY_ROW my_stmt_fetch_next(MY_STMT *stmt, MY_STMT_RES *res) {
 	if (t != 0 && t != MYSQL_DATA_TRUNCATED) {
 		if (t != MYSQL_NO_DATA) {
 			row.has_error = 1;
+		} else {
+			res->warning_count = mysql_warning_count(stmt->mysql);
 		}
 		return row;
 	}
 
 	for (int i = 0; i < stmt->meta.num_fields; i ++) {
-		if (stmt->output_lengths[i] == 0) {
+		// NULL and empty value has nothing to fetch, they are told apart by is_nulls.
+		if (stmt->output_is_nulls[i] || stmt->output_lengths[i] == 0) {
 			continue;
 		}
 
@@ -364,6 +600,7 @@ MY_ROW my_stmt_fetch_next(MY_STMT *stmt, MY_STMT_RES *res) {
 
 	row.mysql_row = (MYSQL_ROW)stmt->row_cache;
 	row.lengths = stmt->output_lengths;
+	row.is_nulls = stmt->output_is_nulls;
 	return row;
 }
 
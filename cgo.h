@@ -15,7 +15,15 @@ typedef enum my_mode {
 // !!! Call this before everything else !!!
 extern void my_library_init(void);
 
+// Initialize the client state of the calling thread.
+extern void my_worker_init(void);
+
+// Release the client state of the calling thread. Call this before the thread exits.
+extern void my_worker_end(void);
+
 // Create a connection. You must call my_close even if my_open fails.
+// connect_timeout is in seconds, ssl_mode is a value of enum mysql_ssl_mode, 0 means the client default.
+// Returns 2 if the ssl_mode is not supported by the client library.
 extern int my_open(
 	MYSQL         *mysql,
 	const char    *host,
@@ -24,7 +32,9 @@ extern int my_open(
 	const char    *db,
 	unsigned int  port,
 	const char    *unix_socket,
-	unsigned long client_flag
+	unsigned long client_flag,
+	unsigned int  connect_timeout,
+	unsigned int  ssl_mode
 );
 
 extern void my_close(MYSQL *mysql);
@@ -42,6 +52,9 @@ extern unsigned int my_errno(MYSQL *mysql);
 // Returns the error message for the most recently invoked MySQL function
 extern const char *my_error(MYSQL *mysql);
 
+// Returns the SQLSTATE error code for the most recently invoked MySQL function
+extern const char *my_sqlstate(MYSQL *mysql);
+
 // Toggles autocommit mode on/off
 extern int my_autocommit(MYSQL *mysql, my_bool mode);
 
@@ -51,6 +64,9 @@ extern int my_commit(MYSQL *mysql);
 // Rollback current transaction
 extern int my_rollback(MYSQL *mysql);
 
+// Checks whether the connection to the server is working
+extern int my_ping(MYSQL *mysql);
+
 // Escapes special characters in a string for use in an SQL statement, 
 // taking into account the current character set of the connection
 extern unsigned long my_real_escape_string(MYSQL *mysql, char *to, const char *from, unsigned long length);
@@ -67,6 +83,7 @@ typedef struct my_res_meta {
 typedef struct my_res {
 	my_ulonglong affected_rows;
 	my_ulonglong insert_id;
+	unsigned int warning_count;
 	MY_RES_META  meta;
 	MYSQL_RES    *result;
 } MY_RES;
@@ -75,7 +92,7 @@ typedef struct my_row {
 	int           has_error;
 	MYSQL_ROW     mysql_row;
 	unsigned long *lengths;
-	my_bool       *is_nulls;
+	my_bool       *is_nulls; // Only set by my_stmt_fetch_next. For my_fetch_next, NULL value has a NULL pointer in mysql_row.
 } MY_ROW;
 
 // mode == MY_MODE_READER uses streaming (use_result). Otherwise it prefetches (store_result).
@@ -85,35 +102,67 @@ extern int my_query(MYSQL *mysql, MY_RES *res, const char *sql_str, unsigned lon
 extern MY_ROW my_fetch_next(MYSQL *mysql, MY_RES *res);
 
 // If my_query has results, you must call this before the next invocation.
+// NOTE: All results after the first are discarded, use my_query_multi to read them.
 extern void my_close_result(MYSQL *mysql, MY_RES *res);
 
+// Send a query which may return multiple results (multi-statements or CALL).
+// Call my_store_result for each result, and my_next_result to move to the next one.
+extern int my_query_multi(MYSQL *mysql, const char *sql_str, unsigned long sql_len);
+
+// Store the current result, or read it row by row by my_fetch_next if mode is MY_MODE_READER.
+// res->meta.num_fields is 0 if the statement has no result set.
+// You must call my_free_result before my_next_result.
+extern int my_store_result(MYSQL *mysql, MY_RES *res, MY_MODE mode);
+
+// Returns 0 if there are more results, -1 if no more results, >0 if an error occurred.
+extern int my_next_result(MYSQL *mysql);
+
+// Returns non-zero if there are more results after the current one.
+// The result of unbuffered reading is known after all rows fetched.
+extern int my_more_results(MYSQL *mysql);
+
+extern void my_free_result(MY_RES *res);
+
 /*
 Prepared Statements
 */
 
 typedef struct my_stmt {
 	MYSQL_STMT    *s;
+	MYSQL         *mysql;
 	unsigned long param_count;
+	char          **param_cache;
+	size_t        *param_cache_len;
+	unsigned long *param_lengths;
+	my_bool       *param_is_nulls;
 	MY_RES_META   meta;
 	my_bool       meta_init;
 	char          **row_cache;
 	size_t        *row_cache_len;
 	MYSQL_BIND    *outputs;
 	unsigned long *output_lengths;
+	my_bool       *output_is_nulls;
 } MY_STMT;
 
 typedef struct my_stmt_res {
 	my_ulonglong affected_rows;
 	my_ulonglong insert_id;
+	unsigned int warning_count;
 } MY_STMT_RES;
 
 
 extern int my_prepare(MY_STMT **stmt, MYSQL_BIND **binds, MYSQL *mysql, const char *sql_str, unsigned long sql_len);
 
+// Copy parameter data into the C-owned buffer of parameter pos, so binds never point to Go memory.
+// The buffer is reused by subsequent calls and grown as needed.
+extern int my_stmt_bind(MY_STMT *stmt, MYSQL_BIND *binds, unsigned long pos, enum enum_field_types type, const void *data, unsigned long length, my_bool is_null, my_bool is_unsigned);
+
 extern int my_stmt_errno(MY_STMT *stmt);
 
 extern const char *my_stmt_error(MY_STMT *stmt);
 
+extern const char *my_stmt_sqlstate(MY_STMT *stmt);
+
 extern int my_stmt_execute(MY_STMT *stmt, MYSQL_BIND *binds, MY_STMT_RES *res, MY_MODE mode);
 
 extern int my_stmt_close(MY_STMT *stmt, MYSQL_BIND *binds);
@@ -122,4 +171,16 @@ extern MY_ROW my_stmt_fetch_next(MY_STMT *stmt, MY_STMT_RES *res);
 
 extern void my_stmt_close_result(MY_STMT *stmt, MY_STMT_RES *res);
 
+// Execute a statement which may return multiple results (CALL).
+// Call my_stmt_store_result for each result, and my_stmt_next_result to move to the next one.
+extern int my_stmt_execute_multi(MY_STMT *stmt, MYSQL_BIND *binds);
+
+// Store the current result and bind outputs for it, the result is read row by row if mode is MY_MODE_READER.
+// stmt->meta.num_fields is 0 if the result has no result set.
+// You must call my_stmt_close_result before my_stmt_next_result if the result is not stored.
+extern int my_stmt_store_result(MY_STMT *stmt, MY_STMT_RES *res, MY_MODE mode);
+
+// Returns 0 if there are more results, -1 if no more results, >0 if an error occurred.
+extern int my_stmt_next_result(MY_STMT *stmt);
+
 #endif
\ No newline at end of file
@@ -5,7 +5,10 @@ package mysql
 */
 import "C"
 import (
+	"fmt"
 	"strings"
+	"sync/atomic"
+	"time"
 	"unsafe"
 )
 
@@ -53,6 +56,16 @@ const (
 	CF_CLIENT_REMEMBER_OPTIONS = ClientFlag(C.CLIENT_REMEMBER_OPTIONS)
 )
 
+// Values of enum mysql_ssl_mode.
+var sslModes = map[string]C.uint{
+	"":                0,
+	"disabled":        1,
+	"preferred":       2,
+	"required":        3,
+	"verify_ca":       4,
+	"verify_identity": 5,
+}
+
 func init() {
 	// This needs to be called before threads begin to spawn.
 	C.my_library_init()
@@ -68,12 +81,32 @@ type ConnectionParams struct {
 	UnixSocket string     `json:"unix"`     // Unix socket path when using unix socket connection.
 	Charset    string     `json:"charset"`  // Connection charactor set.
 	Flags      ClientFlag `json:"-"`        // Client flags. See http://dev.mysql.com/doc/refman/5.6/en/mysql-real-connect.html
+
+	// Connect timeout, rounded up to seconds. Zero means the client default.
+	Timeout time.Duration `json:"timeout"`
+
+	// TLS mode: "disabled", "preferred", "required", "verify_ca" or "verify_identity". Empty means the client default.
+	// Requires MySQL 5.7.11 or later client library.
+	TLS string `json:"tls"`
+
+	// Run all C calls of the connection on a dedicated OS thread, the connection can be shared across goroutines.
+	DedicatedThread bool `json:"dedicated_thread"`
+
+	// Return *WarningError when Execute() or QueryTable() generated warnings. NOTE: DataReader is not checked.
+	StrictWarnings bool `json:"strict_warnings"`
 }
 
 // MySQL connection.
+// The connection is not goroutine-safe unless ConnectionParams.DedicatedThread enabled.
+// NOTE: In dedicated thread mode each call is serialized, but an opened DataReader still
+// occupies the connection, other goroutines must wait it closed before their queries.
 type Connection struct {
 	c      C.MYSQL
-	closed bool
+	closed atomic.Bool // written on the dedicated thread, read from any goroutine.
+	params ConnectionParams
+	errno  int     // number of the last error, used by Pool to detect broken connections.
+	worker *worker // nil when ConnectionParams.DedicatedThread is disabled.
+	tx     *Tx     // the transaction started by Begin() and not finished yet.
 }
 
 // Connect to MySQL server.
@@ -95,17 +128,36 @@ func Connect(params ConnectionParams) (conn *Connection, err error) {
 
 	port := C.uint(params.Port)
 	flags := C.ulong(params.Flags)
+	timeout := C.uint((params.Timeout + time.Second - 1) / time.Second)
 
-	conn = &Connection{}
+	sslMode, ok := sslModes[params.TLS]
+	if !ok {
+		return nil, fmt.Errorf("mysql: unknown TLS mode %q", params.TLS)
+	}
 
-	if C.my_open(&conn.c, host, uname, pass, dbname, port, unix_socket, flags) != 0 {
-		defer conn.Close()
-		return nil, conn.lastError("")
+	conn = &Connection{params: params}
+	if params.DedicatedThread {
+		conn.worker = newWorker()
+	}
+
+	conn.do(func() {
+		switch C.my_open(&conn.c, host, uname, pass, dbname, port, unix_socket, flags, timeout, sslMode) {
+		case 0:
+		case 2:
+			err = fmt.Errorf("mysql: TLS mode %q is not supported by the client library", params.TLS)
+		default:
+			err = conn.lastError("connect", "")
+		}
+	})
+	if err != nil {
+		conn.Close()
+		return nil, err
 	}
 
 	if charset := strings.TrimSpace(params.Charset); charset != "" {
 		_, err = conn.Execute("set names " + params.Charset)
 		if err != nil {
+			conn.Close()
 			return nil, err
 		}
 	}
@@ -113,61 +165,147 @@ func Connect(params ConnectionParams) (conn *Connection, err error) {
 }
 
 // Get current connection thread id.
-func (conn *Connection) Id() int64 {
-	return int64(C.my_thread_id(&conn.c))
+func (conn *Connection) Id() (id int64) {
+	conn.do(func() {
+		id = int64(C.my_thread_id(&conn.c))
+	})
+	return
 }
 
 // Close connection.
 func (conn *Connection) Close() {
-	if !conn.closed {
-		C.my_close(&conn.c)
-		conn.closed = true
+	closeConn := func() {
+		if !conn.closed.Load() {
+			C.my_close(&conn.c)
+			conn.closed.Store(true)
+		}
+	}
+	if conn.worker != nil {
+		conn.worker.stop(closeConn)
+		return
 	}
+	closeConn()
 }
 
 // Check connection is closed or not.
 func (conn *Connection) IsClosed() bool {
-	return conn.closed
+	return conn.closed.Load()
+}
+
+// Checks whether the connection to the server is working.
+func (conn *Connection) Ping() (err error) {
+	if conn.IsClosed() {
+		return conn.closedError("ping", "")
+	}
+	conn.do(func() {
+		if C.my_ping(&conn.c) != 0 {
+			err = conn.lastError("ping", "")
+		}
+	})
+	return
 }
 
 // Toggles autocommit mode on/off
-func (conn *Connection) Autocommit(mode bool) error {
+func (conn *Connection) Autocommit(mode bool) (err error) {
 	m := C.my_bool(0)
 	if mode {
 		m = C.my_bool(1)
 	}
-	if C.my_autocommit(&conn.c, m) != 0 {
-		return conn.lastError("")
-	}
-	return nil
+	conn.do(func() {
+		if C.my_autocommit(&conn.c, m) != 0 {
+			err = conn.lastError("autocommit", "")
+		}
+	})
+	return
 }
 
 // Commit current transaction
-func (conn *Connection) Commit() error {
-	if C.my_commit(&conn.c) != 0 {
-		return conn.lastError("")
-	}
-	return nil
+func (conn *Connection) Commit() (err error) {
+	conn.do(func() {
+		conn.endTx()
+		if C.my_commit(&conn.c) != 0 {
+			err = conn.lastError("commit", "")
+		}
+	})
+	return
 }
 
 // Rollback current transaction
-func (conn *Connection) Rollback() error {
-	if C.my_rollback(&conn.c) != 0 {
-		return conn.lastError("")
-	}
-	return nil
+func (conn *Connection) Rollback() (err error) {
+	conn.do(func() {
+		conn.endTx()
+		if C.my_rollback(&conn.c) != 0 {
+			err = conn.lastError("rollback", "")
+		}
+	})
+	return
 }
 
 // Escapes special characters in a string for use in an SQL statement,
 // taking into account the current character set of the connection.
 func (conn *Connection) Escape(from string) string {
 	to := make([]byte, len(from)*2+1)
-	length := C.my_real_escape_string(&conn.c, (*C.char)(bytePointer(to)), (*C.char)(stringPointer(from)), C.ulong(len(from)))
+	var length C.ulong
+	conn.do(func() {
+		length = C.my_real_escape_string(&conn.c, (*C.char)(bytePointer(to)), (*C.char)(stringPointer(from)), C.ulong(len(from)))
+	})
 	return string(to[:length])
 }
 
 // Execute a non-query SQL.
-func (conn *Connection) Execute(sql string) (Result, error) {
+func (conn *Connection) Execute(sql string) (res Result, err error) {
+	conn.do(func() {
+		res, err = conn.executeResult(sql)
+		if err == nil {
+			err = conn.checkWarnings(res)
+		}
+	})
+	return
+}
+
+// Execute a query and fill result into a DataTable.
+func (conn *Connection) QueryTable(sql string) (res DataTable, err error) {
+	conn.do(func() {
+		res, err = conn.queryTable(sql)
+		if err == nil {
+			err = conn.checkWarnings(res)
+		}
+	})
+	return
+}
+
+// Execute a query and return the result reader. NOTE: Please remember close the reader.
+func (conn *Connection) QueryReader(sql string) (res DataReader, err error) {
+	conn.do(func() {
+		res, err = conn.queryReader(sql)
+	})
+	return
+}
+
+// Execute multiple statements or a stored procedure and iterate all the results.
+// Multiple statements require the CF_CLIENT_MULTI_STATEMENTS flag. NOTE: Please remember close the result.
+func (conn *Connection) QueryMulti(sql string) (res MultiResult, err error) {
+	conn.do(func() {
+		res, err = conn.queryMulti(sql)
+	})
+	return
+}
+
+// Run f on the dedicated thread if there is one.
+func (conn *Connection) do(f func()) {
+	// Clear the last error number first, so Pool only sees the error of the latest call.
+	call := func() {
+		conn.errno = 0
+		f()
+	}
+	if conn.worker != nil {
+		conn.worker.do(call)
+		return
+	}
+	call()
+}
+
+func (conn *Connection) executeResult(sql string) (Result, error) {
 	res := &connResult{
 		m: &conn.c,
 	}
@@ -180,8 +318,7 @@ func (conn *Connection) Execute(sql string) (Result, error) {
 	return res, nil
 }
 
-// Execute a query and fill result into a DataTable.
-func (conn *Connection) QueryTable(sql string) (DataTable, error) {
+func (conn *Connection) queryTable(sql string) (DataTable, error) {
 	res := &connDataTable{}
 	res.m = &conn.c
 
@@ -199,8 +336,7 @@ func (conn *Connection) QueryTable(sql string) (DataTable, error) {
 	return res, nil
 }
 
-// Execute a query and return the result reader. NOTE: Please remember close the reader.
-func (conn *Connection) QueryReader(sql string) (DataReader, error) {
+func (conn *Connection) queryReader(sql string) (DataReader, error) {
 	res := &connDataReader{}
 	res.m = &conn.c
 
@@ -212,18 +348,44 @@ func (conn *Connection) QueryReader(sql string) (DataReader, error) {
 	return res, nil
 }
 
+func (conn *Connection) queryMulti(sql string) (MultiResult, error) {
+	if conn.IsClosed() {
+		return nil, conn.closedError("query", sql)
+	}
+	if C.my_query_multi(&conn.c, (*C.char)(stringPointer(sql)), C.ulong(len(sql))) != 0 {
+		return nil, conn.lastError("query", sql)
+	}
+	return &connMultiResult{conn: conn, sql: sql}, nil
+}
+
 func cfree(str *C.char) {
 	if str != nil {
 		C.free(unsafe.Pointer(str))
 	}
 }
 
+// Get the operation name of mode used in errors.
+func modeOp(mode C.MY_MODE) string {
+	if mode == C.MY_MODE_NONE {
+		return "execute"
+	}
+	return "query"
+}
+
+// Get thread id without conn.do(), 0 if the connection is closed.
+func (conn *Connection) threadId() int64 {
+	if conn.closed.Load() {
+		return 0
+	}
+	return int64(C.my_thread_id(&conn.c))
+}
+
 func (conn *Connection) execute(sql string, res *connResult, mode C.MY_MODE) error {
 	if conn.IsClosed() {
-		return &SqlError{Num: 2006, Message: "Connection is closed"}
+		return conn.closedError(modeOp(mode), sql)
 	}
 	if C.my_query(&conn.c, &res.c, (*C.char)(stringPointer(sql)), C.ulong(len(sql)), mode) != 0 {
-		return conn.lastError(sql)
+		return conn.lastError(modeOp(mode), sql)
 	}
 	return nil
 }
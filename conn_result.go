@@ -31,6 +31,10 @@ func (res *connResult) InsertId() int64 {
 	return int64(res.c.insert_id)
 }
 
+func (res *connResult) WarningCount() int {
+	return int(res.c.warning_count)
+}
+
 func (res *connResult) close() {
 	C.my_close_result(res.m, &res.c)
 }
@@ -48,22 +52,35 @@ func fetchFields(c C.MY_RES_META) []Field {
 	}
 
 	cfields := (*[maxSize]C.MYSQL_FIELD)(unsafe.Pointer(c.fields))
-	totalLength := uint64(0)
-	for i := 0; i < nfields; i++ {
-		totalLength += uint64(cfields[i].name_length)
-	}
 
 	fields := make([]Field, nfields)
 	for i := 0; i < nfields; i++ {
-		length := cfields[i].name_length
-		fname := (*[maxSize]byte)(unsafe.Pointer(cfields[i].name))[:length]
-		fields[i].Name = string(fname)
-		fields[i].Type = TypeCode(cfields[i]._type)
+		cf := &cfields[i]
+		fields[i] = Field{
+			Name:      fieldString(cf.name, cf.name_length),
+			OrgName:   fieldString(cf.org_name, cf.org_name_length),
+			Table:     fieldString(cf.table, cf.table_length),
+			OrgTable:  fieldString(cf.org_table, cf.org_table_length),
+			Database:  fieldString(cf.db, cf.db_length),
+			Type:      TypeCode(cf._type),
+			Charset:   int(cf.charsetnr),
+			Length:    int64(cf.length),
+			MaxLength: int64(cf.max_length),
+			Decimals:  int(cf.decimals),
+			Flags:     FieldFlag(cf.flags),
+		}
 	}
 
 	return fields
 }
 
+func fieldString(str *C.char, length C.uint) string {
+	if str == nil || length == 0 {
+		return ""
+	}
+	return string((*[maxSize]byte)(unsafe.Pointer(str))[:length])
+}
+
 func fetchNext(c C.MY_RES_META, crow C.MY_ROW, isStmt bool) (row []Value, err error) {
 	rowPtr := (*[maxSize]*[maxSize]byte)(unsafe.Pointer(crow.mysql_row))
 	if rowPtr == nil {
@@ -76,21 +93,35 @@ func fetchNext(c C.MY_RES_META, crow C.MY_ROW, isStmt bool) (row []Value, err er
 	row = make([]Value, colCount)
 
 	lengths := (*[maxSize]uint64)(unsafe.Pointer(crow.lengths))
+	isNulls := (*[maxSize]C.my_bool)(unsafe.Pointer(crow.is_nulls))
 	totalLength := uint64(0)
 	for i := 0; i < colCount; i++ {
 		totalLength += lengths[i]
 	}
 
+	// NOTE: the arena is never nil, so empty values not be treated as NULL.
 	arena := make([]byte, 0, int(totalLength))
 	for i := 0; i < colCount; i++ {
+		row[i] = Value{
+			isStmtValue: isStmt,
+			isUnsigned:  FieldFlag(cfields[i].flags)&UNSIGNED_FLAG != 0,
+			Type:        TypeCode(cfields[i]._type),
+		}
+
 		colLength := lengths[i]
 		colPtr := rowPtr[i]
-		if colPtr == nil {
+		if isNulls != nil {
+			if isNulls[i] != 0 {
+				continue
+			}
+		} else if colPtr == nil {
 			continue
 		}
 		start := len(arena)
-		arena = append(arena, colPtr[:colLength]...)
-		row[i] = Value{isStmt, TypeCode(cfields[i]._type), arena[start : start+int(colLength)]}
+		if colLength != 0 {
+			arena = append(arena, colPtr[:colLength]...)
+		}
+		row[i].Inner = arena[start : start+int(colLength)]
 	}
 
 	return row, nil
@@ -103,7 +134,7 @@ func (res *connQueryResult) fillFields() {
 func (res *connQueryResult) fetchNext() (row []Value, err error) {
 	crow := C.my_fetch_next(res.m, &res.c)
 	if crow.has_error != 0 {
-		return nil, res.conn.lastError("")
+		return nil, res.conn.lastError("fetch", "")
 	}
 
 	return fetchNext(res.c.meta, crow, false)
@@ -134,7 +165,7 @@ func (res *connDataTable) fillRows(conn *Connection) (err error) {
 	}
 
 	if rowCount < 0 {
-		return conn.lastError("")
+		return conn.lastError("fetch", "")
 	}
 
 	rows := make([][]Value, rowCount)
@@ -154,14 +185,181 @@ func (res *connDataTable) Rows() [][]Value {
 	return res.rows
 }
 
+func (res *connDataTable) ScanAll(dst interface{}) error {
+	return scanAll(res.fields, res.rows, dst)
+}
+
 type connDataReader struct {
 	connQueryResult
+	row []Value // the last fetched row.
+}
+
+func (res *connDataReader) FetchNext() (row []Value, err error) {
+	res.conn.do(func() {
+		row, err = res.fetchNext()
+	})
+	res.row = row
+	return
 }
 
-func (res *connDataReader) FetchNext() ([]Value, error) {
-	return res.fetchNext()
+func (res *connDataReader) Scan(dest ...interface{}) error {
+	return ScanRow(res.row, dest...)
+}
+
+func (res *connDataReader) ScanStruct(dst interface{}) error {
+	return scanRowStruct(res.fields, res.row, dst)
 }
 
 func (res *connDataReader) Close() {
-	res.close()
+	res.conn.do(res.close)
+}
+
+type connMultiResult struct {
+	conn    *Connection
+	sql     string
+	started bool
+	done    bool
+	err     error
+	reader  *connMultiReader // the reader of current result, closed before moving to the next result.
+}
+
+func (mr *connMultiResult) NextResult() (res DataTable, ok bool) {
+	mr.conn.do(func() {
+		res, ok = mr.nextResult(true)
+	})
+	return
+}
+
+func (mr *connMultiResult) NextReader() (res DataReader, ok bool) {
+	mr.conn.do(func() {
+		res, ok = mr.nextReader()
+	})
+	return
+}
+
+func (mr *connMultiResult) HasNext() (ok bool) {
+	mr.conn.do(func() {
+		ok = !mr.done && (!mr.started || C.my_more_results(&mr.conn.c) != 0)
+	})
+	return
+}
+
+func (mr *connMultiResult) Err() error {
+	return mr.err
+}
+
+func (mr *connMultiResult) Close() {
+	mr.conn.do(func() {
+		for !mr.done {
+			mr.nextResult(false)
+		}
+	})
+}
+
+// Move to the next result, returns false when no more results or an error occurred.
+func (mr *connMultiResult) next() bool {
+	if mr.done {
+		return false
+	}
+
+	if mr.reader != nil {
+		mr.reader.close()
+		mr.reader = nil
+	}
+
+	conn := mr.conn
+	if mr.started {
+		switch C.my_next_result(&conn.c) {
+		case 0:
+		case -1:
+			mr.done = true
+			return false
+		default:
+			mr.err = conn.lastError("next result", mr.sql)
+			mr.done = true
+			return false
+		}
+	}
+	mr.started = true
+	return true
+}
+
+func (mr *connMultiResult) nextResult(fill bool) (DataTable, bool) {
+	if !mr.next() {
+		return nil, false
+	}
+
+	conn := mr.conn
+	res := &connDataTable{}
+	res.m = &conn.c
+	if C.my_store_result(&conn.c, &res.c, C.MY_MODE_TABLE) != 0 {
+		mr.err = conn.lastError("next result", mr.sql)
+		mr.done = true
+		return nil, false
+	}
+	defer C.my_free_result(&res.c)
+
+	if !fill {
+		return nil, true
+	}
+
+	res.conn = conn
+	res.fillFields()
+	if len(res.fields) != 0 {
+		if err := res.fillRows(conn); err != nil {
+			mr.err = err
+			mr.done = true
+			return nil, false
+		}
+	}
+	return res, true
+}
+
+func (mr *connMultiResult) nextReader() (DataReader, bool) {
+	if !mr.next() {
+		return nil, false
+	}
+
+	conn := mr.conn
+	res := &connMultiReader{}
+	res.m = &conn.c
+	if C.my_store_result(&conn.c, &res.c, C.MY_MODE_READER) != 0 {
+		mr.err = conn.lastError("next result", mr.sql)
+		mr.done = true
+		return nil, false
+	}
+
+	res.conn = conn
+	res.fillFields()
+	mr.reader = res
+	return res, true
+}
+
+// Reader of a result in connMultiResult. Closing it keeps the following results.
+type connMultiReader struct {
+	connDataReader
+	closed bool
+}
+
+// Returns nil after the reader closed, the connection may be reading the next result.
+func (res *connMultiReader) FetchNext() (row []Value, err error) {
+	res.conn.do(func() {
+		if !res.closed {
+			row, err = res.fetchNext()
+		}
+	})
+	res.row = row
+	return
+}
+
+func (res *connMultiReader) Close() {
+	res.conn.do(res.close)
+}
+
+func (res *connMultiReader) close() {
+	if !res.closed {
+		// Fetch the remaining rows, so the next result can be read.
+		C.my_free_result(&res.c)
+		res.closed = true
+	}
 }
@@ -0,0 +1,201 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"strconv"
+)
+
+// Execute a non-query SQL, the query is killed when ctx is done.
+func (conn *Connection) ExecuteContext(ctx context.Context, sql string) (Result, error) {
+	var res Result
+	err := conn.withContext(ctx, func() (err error) {
+		res, err = conn.Execute(sql)
+		return
+	})
+	// The result is kept with *WarningError.
+	if _, ok := err.(*ContextError); ok {
+		return nil, err
+	}
+	return res, err
+}
+
+// Execute a query and fill result into a DataTable, the query is killed when ctx is done.
+func (conn *Connection) QueryTableContext(ctx context.Context, sql string) (DataTable, error) {
+	var res DataTable
+	err := conn.withContext(ctx, func() (err error) {
+		res, err = conn.QueryTable(sql)
+		return
+	})
+	// The result is kept with *WarningError.
+	if _, ok := err.(*ContextError); ok {
+		return nil, err
+	}
+	return res, err
+}
+
+// Execute a query and return the result reader, ctx only covers the query, not the following FetchNext() calls.
+func (conn *Connection) QueryReaderContext(ctx context.Context, sql string) (DataReader, error) {
+	var res DataReader
+	err := conn.withContext(ctx, func() (err error) {
+		res, err = conn.QueryReader(sql)
+		return
+	})
+	if err != nil {
+		if res != nil {
+			res.Close()
+		}
+		return nil, err
+	}
+	return res, nil
+}
+
+// Execute multiple statements or a stored procedure, ctx only covers the first result.
+func (conn *Connection) QueryMultiContext(ctx context.Context, sql string) (MultiResult, error) {
+	var res MultiResult
+	err := conn.withContext(ctx, func() (err error) {
+		res, err = conn.QueryMulti(sql)
+		return
+	})
+	if err != nil {
+		if res != nil {
+			res.Close()
+		}
+		return nil, err
+	}
+	return res, nil
+}
+
+// Prepare a statement, the prepare is killed when ctx is done.
+func (conn *Connection) PrepareContext(ctx context.Context, sql string) (*Stmt, error) {
+	var stmt *Stmt
+	err := conn.withContext(ctx, func() (err error) {
+		stmt, err = conn.Prepare(sql)
+		return
+	})
+	if err != nil {
+		if stmt != nil {
+			stmt.Close()
+		}
+		return nil, err
+	}
+	return stmt, nil
+}
+
+// Execute statement as none-query, the statement is killed when ctx is done.
+func (stmt *Stmt) ExecuteContext(ctx context.Context) (Result, error) {
+	var res Result
+	err := stmt.conn.withContext(ctx, func() (err error) {
+		res, err = stmt.Execute()
+		return
+	})
+	// The result is kept with *WarningError.
+	if _, ok := err.(*ContextError); ok {
+		return nil, err
+	}
+	return res, err
+}
+
+// Execute statement and fill result into a DataTable, the statement is killed when ctx is done.
+func (stmt *Stmt) QueryTableContext(ctx context.Context) (DataTable, error) {
+	var res DataTable
+	err := stmt.conn.withContext(ctx, func() (err error) {
+		res, err = stmt.QueryTable()
+		return
+	})
+	// The result is kept with *WarningError.
+	if _, ok := err.(*ContextError); ok {
+		return nil, err
+	}
+	return res, err
+}
+
+// Execute statement and return a result reader, ctx only covers the execution, not the following FetchNext() calls.
+func (stmt *Stmt) QueryReaderContext(ctx context.Context) (DataReader, error) {
+	var res DataReader
+	err := stmt.conn.withContext(ctx, func() (err error) {
+		res, err = stmt.QueryReader()
+		return
+	})
+	if err != nil {
+		if res != nil {
+			res.Close()
+		}
+		return nil, err
+	}
+	return res, nil
+}
+
+// Execute statement and iterate all the results, ctx only covers the first result.
+func (stmt *Stmt) QueryMultiContext(ctx context.Context) (MultiResult, error) {
+	var res MultiResult
+	err := stmt.conn.withContext(ctx, func() (err error) {
+		res, err = stmt.QueryMulti()
+		return
+	})
+	if err != nil {
+		if res != nil {
+			res.Close()
+		}
+		return nil, err
+	}
+	return res, nil
+}
+
+// Run f and watch ctx at the same time. If ctx is done before f returned, the running query is killed
+// by "KILL QUERY" through a side connection, because the MYSQL handle is blocked by f.
+// Returns a ContextError if the query killed, even f succeeded, e.g. a killed "SELECT SLEEP(10)" returns 1.
+func (conn *Connection) withContext(ctx context.Context, f func() error) error {
+	if ctx.Done() == nil || conn.IsClosed() {
+		return f()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return &ContextError{Err: err}
+	}
+
+	// Get thread id before f running, the MYSQL handle can't be used by two threads at the same time.
+	id := conn.Id()
+
+	done := make(chan struct{})
+	killed := make(chan bool, 1)
+	var killErr error
+	go func() {
+		select {
+		case <-ctx.Done():
+			// Don't kill when f already returned, ctx and f maybe done at the same time.
+			select {
+			case <-done:
+				killed <- false
+			default:
+				killErr = killQuery(conn.params, id)
+				killed <- true
+			}
+		case <-done:
+			killed <- false
+		}
+	}()
+
+	err := f()
+	close(done)
+
+	// Wait the watcher, so the "KILL QUERY" will not hit the next query.
+	if <-killed {
+		return &ContextError{Err: ctx.Err(), Cause: errors.Join(err, killErr)}
+	}
+	return err
+}
+
+// Kill the running query of connection thread id through a side connection.
+func killQuery(params ConnectionParams, id int64) error {
+	params.Charset = ""
+
+	conn, err := Connect(params)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Execute("KILL QUERY " + strconv.FormatInt(id, 10))
+	return err
+}
@@ -0,0 +1,169 @@
+package driver
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"github.com/funny/mysql"
+	"reflect"
+	"time"
+)
+
+// Character set number of binary strings.
+const binaryCharset = 63
+
+var (
+	scanTypeInt64      = reflect.TypeOf(int64(0))
+	scanTypeUint64     = reflect.TypeOf(uint64(0))
+	scanTypeFloat64    = reflect.TypeOf(float64(0))
+	scanTypeString     = reflect.TypeOf("")
+	scanTypeBytes      = reflect.TypeOf([]byte(nil))
+	scanTypeTime       = reflect.TypeOf(time.Time{})
+	scanTypeNullInt64  = reflect.TypeOf(sql.NullInt64{})
+	scanTypeNullUint64 = reflect.TypeOf(sql.Null[uint64]{})
+	scanTypeNullFloat  = reflect.TypeOf(sql.NullFloat64{})
+	scanTypeNullString = reflect.TypeOf(sql.NullString{})
+	scanTypeNullTime   = reflect.TypeOf(sql.NullTime{})
+	scanTypeUnknown    = reflect.TypeOf((*interface{})(nil)).Elem()
+)
+
+func (r *MySqlRows) field(index int) *mysql.Field {
+	return &r.rows.Fields()[index]
+}
+
+// Get the database type name of column, e.g. "INT", "UNSIGNED BIGINT", "VARCHAR" or "TEXT".
+func (r *MySqlRows) ColumnTypeDatabaseTypeName(index int) string {
+	field := r.field(index)
+	binary := field.Charset == binaryCharset
+
+	switch field.Type {
+	case mysql.MYSQL_TYPE_TINY, mysql.MYSQL_TYPE_SHORT, mysql.MYSQL_TYPE_INT24,
+		mysql.MYSQL_TYPE_LONG, mysql.MYSQL_TYPE_LONGLONG:
+		if field.IsUnsigned() {
+			return "UNSIGNED " + field.Type.String()
+		}
+	case mysql.MYSQL_TYPE_STRING:
+		switch {
+		case field.IsEnum():
+			return "ENUM"
+		case field.IsSet():
+			return "SET"
+		case binary:
+			return "BINARY"
+		}
+	case mysql.MYSQL_TYPE_VAR_STRING:
+		if binary {
+			return "VARBINARY"
+		}
+	case mysql.MYSQL_TYPE_TINY_BLOB, mysql.MYSQL_TYPE_BLOB, mysql.MYSQL_TYPE_MEDIUM_BLOB, mysql.MYSQL_TYPE_LONG_BLOB:
+		if !binary {
+			// TINYBLOB to TINYTEXT, etc.
+			name := field.Type.String()
+			return name[:len(name)-4] + "TEXT"
+		}
+	}
+	return field.Type.String()
+}
+
+// Get the Go type of column values returned by Next(). Nullable columns use the sql.NullXxx types.
+func (r *MySqlRows) ColumnTypeScanType(index int) reflect.Type {
+	return scanType(r.field(index))
+}
+
+func scanType(field *mysql.Field) reflect.Type {
+	nullable := field.Nullable()
+
+	switch field.Type {
+	case mysql.MYSQL_TYPE_TINY, mysql.MYSQL_TYPE_SHORT, mysql.MYSQL_TYPE_INT24,
+		mysql.MYSQL_TYPE_LONG, mysql.MYSQL_TYPE_LONGLONG, mysql.MYSQL_TYPE_YEAR:
+		switch {
+		case field.IsUnsigned() && nullable:
+			return scanTypeNullUint64
+		case field.IsUnsigned():
+			return scanTypeUint64
+		case nullable:
+			return scanTypeNullInt64
+		}
+		return scanTypeInt64
+	case mysql.MYSQL_TYPE_FLOAT, mysql.MYSQL_TYPE_DOUBLE:
+		if nullable {
+			return scanTypeNullFloat
+		}
+		return scanTypeFloat64
+	case mysql.MYSQL_TYPE_DATE, mysql.MYSQL_TYPE_NEWDATE, mysql.MYSQL_TYPE_DATETIME, mysql.MYSQL_TYPE_TIMESTAMP:
+		if nullable {
+			return scanTypeNullTime
+		}
+		return scanTypeTime
+	case mysql.MYSQL_TYPE_BIT, mysql.MYSQL_TYPE_GEOMETRY:
+		return scanTypeBytes
+	case mysql.MYSQL_TYPE_STRING, mysql.MYSQL_TYPE_VAR_STRING, mysql.MYSQL_TYPE_ENUM, mysql.MYSQL_TYPE_SET,
+		mysql.MYSQL_TYPE_TINY_BLOB, mysql.MYSQL_TYPE_BLOB, mysql.MYSQL_TYPE_MEDIUM_BLOB, mysql.MYSQL_TYPE_LONG_BLOB:
+		if field.Charset == binaryCharset {
+			return scanTypeBytes
+		}
+		fallthrough
+	case mysql.MYSQL_TYPE_DECIMAL, mysql.MYSQL_TYPE_NEWDECIMAL, mysql.MYSQL_TYPE_TIME:
+		// DECIMAL keeps the precision as string, TIME can be out of the time.Time range.
+		if nullable {
+			return scanTypeNullString
+		}
+		return scanTypeString
+	}
+	return scanTypeUnknown
+}
+
+// Convert value to the type reported by scanType(), so both protocols return the same types.
+func driverValue(field *mysql.Field, v *mysql.Value) (driver.Value, error) {
+	if v.IsNull() {
+		return nil, nil
+	}
+	switch scanType(field) {
+	case scanTypeInt64, scanTypeNullInt64:
+		return v.TryInt64()
+	case scanTypeUint64, scanTypeNullUint64:
+		return v.TryUint64()
+	case scanTypeFloat64, scanTypeNullFloat:
+		return v.TryFloat64()
+	case scanTypeTime, scanTypeNullTime:
+		return v.Time(time.UTC)
+	case scanTypeBytes:
+		return v.Inner, nil
+	case scanTypeString, scanTypeNullString:
+		return v.String(), nil
+	}
+	return v.Interface(), nil
+}
+
+// Check the column can be NULL.
+func (r *MySqlRows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	return r.field(index).Nullable(), true
+}
+
+// Get the length of string and binary columns in bytes, as specified in the table definition.
+func (r *MySqlRows) ColumnTypeLength(index int) (length int64, ok bool) {
+	field := r.field(index)
+	switch field.Type {
+	case mysql.MYSQL_TYPE_STRING, mysql.MYSQL_TYPE_VAR_STRING, mysql.MYSQL_TYPE_ENUM, mysql.MYSQL_TYPE_SET,
+		mysql.MYSQL_TYPE_TINY_BLOB, mysql.MYSQL_TYPE_BLOB, mysql.MYSQL_TYPE_MEDIUM_BLOB, mysql.MYSQL_TYPE_LONG_BLOB:
+		return field.Length, true
+	}
+	return 0, false
+}
+
+// Get the precision and scale of DECIMAL columns.
+func (r *MySqlRows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
+	field := r.field(index)
+	switch field.Type {
+	case mysql.MYSQL_TYPE_DECIMAL, mysql.MYSQL_TYPE_NEWDECIMAL:
+		// The display length includes the sign and the decimal point.
+		precision = field.Length
+		if !field.IsUnsigned() {
+			precision--
+		}
+		if field.Decimals > 0 {
+			precision--
+		}
+		return precision, int64(field.Decimals), true
+	}
+	return 0, 0, false
+}
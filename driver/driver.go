@@ -1,10 +1,16 @@
 package driver
 
 import (
+	"context"
+	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/funny/mysql"
 	"io"
+	"strings"
+	"time"
 )
 
 // MySQL connection parameter.
@@ -16,98 +22,216 @@ type connParams struct {
 	DbName     string `json:"database"` // database name.
 	UnixSocket string `json:"unix"`     // Unix socket path when using unix socket connection.
 	Charset    string `json:"charset"`  // Connection charactor set.
-	Flags      string `json:"flags"`    // Client flags. See http://dev.mysql.com/doc/refman/5.6/en/mysql-real-connect.html
+	Flags      string `json:"flags"`    // Client flags, e.g. "FOUND_ROWS|MULTI_STATEMENTS". See ParseClientFlags().
+
+	Timeout         string `json:"timeout,omitempty"`          // Connect timeout, e.g. "5s".
+	TLS             string `json:"tls,omitempty"`              // TLS mode, see mysql.ConnectionParams.
+	DedicatedThread bool   `json:"dedicated_thread,omitempty"` // See mysql.ConnectionParams.
+	StrictWarnings  bool   `json:"strict_warnings,omitempty"`  // See mysql.ConnectionParams.
 }
 
-type MySqlDriver struct {
+func (params *connParams) connectionParams() (mysql.ConnectionParams, error) {
+	var timeout time.Duration
+	if params.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(params.Timeout); err != nil {
+			return mysql.ConnectionParams{}, fmt.Errorf("mysql: invalid timeout %q", params.Timeout)
+		}
+	}
+	flags, err := ParseClientFlags(params.Flags)
+	if err != nil {
+		return mysql.ConnectionParams{}, err
+	}
+	return mysql.ConnectionParams{
+		Host:            params.Host,
+		Port:            params.Port,
+		Uname:           params.Uname,
+		Pass:            params.Pass,
+		DbName:          params.DbName,
+		UnixSocket:      params.UnixSocket,
+		Charset:         params.Charset,
+		Flags:           flags,
+		Timeout:         timeout,
+		TLS:             params.TLS,
+		DedicatedThread: params.DedicatedThread,
+		StrictWarnings:  params.StrictWarnings,
+	}, nil
 }
 
-func (d MySqlDriver) Open(name string) (driver.Conn, error) {
+func parseName(name string) (mysql.ConnectionParams, error) {
+	if !strings.HasPrefix(strings.TrimSpace(name), "{") {
+		return ParseDSN(name)
+	}
 	params := connParams{}
 	if err := json.Unmarshal([]byte(name), &params); err != nil {
+		return mysql.ConnectionParams{}, err
+	}
+	return params.connectionParams()
+}
+
+type MySqlDriver struct {
+}
+
+// Open a connection. The name is a data source name parsed by ParseDSN(), or a JSON object of connection parameters.
+func (d MySqlDriver) Open(name string) (driver.Conn, error) {
+	connector, err := d.OpenConnector(name)
+	if err != nil {
 		return nil, err
 	}
+	return connector.Connect(context.Background())
+}
 
-	conn, err := mysql.Connect(mysql.ConnectionParams{
-		Host:       params.Host,
-		Port:       params.Port,
-		Uname:      params.Uname,
-		Pass:       params.Pass,
-		DbName:     params.DbName,
-		UnixSocket: params.UnixSocket,
-		Charset:    params.Charset,
-	})
+// Parse the name once and return a connector, used by database/sql instead of Open().
+func (d MySqlDriver) OpenConnector(name string) (driver.Connector, error) {
+	params, err := parseName(name)
 	if err != nil {
 		return nil, err
 	}
+	return NewConnector(params), nil
+}
+
+// Connector with fixed connection parameters.
+type MySqlConnector struct {
+	params mysql.ConnectionParams
+}
 
-	return &MySqlConn{*conn}, nil
+// Create a connector, use sql.OpenDB() to open a database without data source name.
+func NewConnector(params mysql.ConnectionParams) *MySqlConnector {
+	return &MySqlConnector{params}
 }
 
+// Open a connection. The connect timeout is limited by the ctx deadline.
+func (c *MySqlConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	params := c.params
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout := time.Until(deadline)
+		if timeout <= 0 {
+			return nil, context.DeadlineExceeded
+		}
+		if params.Timeout == 0 || timeout < params.Timeout {
+			params.Timeout = timeout
+		}
+	}
+
+	conn, err := mysql.Connect(params)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &MySqlConn{conn: conn}, nil
+}
+
+func (c *MySqlConnector) Driver() driver.Driver {
+	return MySqlDriver{}
+}
+
+var (
+	_ driver.Conn               = (*MySqlConn)(nil)
+	_ driver.ConnBeginTx        = (*MySqlConn)(nil)
+	_ driver.ConnPrepareContext = (*MySqlConn)(nil)
+	_ driver.ExecerContext      = (*MySqlConn)(nil)
+	_ driver.QueryerContext     = (*MySqlConn)(nil)
+	_ driver.Pinger             = (*MySqlConn)(nil)
+	_ driver.SessionResetter    = (*MySqlConn)(nil)
+	_ driver.Validator          = (*MySqlConn)(nil)
+	_ driver.NamedValueChecker  = (*MySqlConn)(nil)
+	_ driver.StmtExecContext    = (*MySqlStmt)(nil)
+	_ driver.StmtQueryContext   = (*MySqlStmt)(nil)
+
+	_ driver.RowsColumnTypeDatabaseTypeName = (*MySqlRows)(nil)
+	_ driver.RowsColumnTypeScanType         = (*MySqlRows)(nil)
+	_ driver.RowsColumnTypeNullable         = (*MySqlRows)(nil)
+	_ driver.RowsColumnTypeLength           = (*MySqlRows)(nil)
+	_ driver.RowsColumnTypePrecisionScale   = (*MySqlRows)(nil)
+	_ driver.RowsNextResultSet              = (*MySqlRows)(nil)
+)
+
 type MySqlConn struct {
-	conn mysql.Connection
+	conn *mysql.Connection
+	bad  bool // The connection is lost, database/sql should discard it.
 }
 
 func (c *MySqlConn) Exec(query string, args []driver.Value) (driver.Result, error) {
+	return c.ExecContext(context.Background(), query, namedValues(args))
+}
+
+// Execute a SQL, the query is killed when ctx is done. Args are bound by a prepared statement.
+func (c *MySqlConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
 	if len(args) == 0 {
-		result, err := c.conn.Execute(query)
+		result, err := c.conn.ExecuteContext(ctx, query)
 		if err != nil {
-			return nil, err
+			return nil, c.check(err)
 		}
 		return &MySqlResult{result}, nil
 	}
 
-	stmt, err1 := c.conn.Prepare(query)
-	if err1 != nil {
-		return nil, err1
+	stmt, err := c.conn.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, c.check(err)
 	}
 	defer stmt.Close()
 
-	for i := 0; i < len(args); i++ {
-		stmt.Bind(args[i])
+	if err := bindArgs(stmt, args); err != nil {
+		return nil, err
 	}
 
-	result, err2 := stmt.Execute()
-	if err2 != nil {
-		return nil, err2
+	result, err := stmt.ExecuteContext(ctx)
+	if err != nil {
+		return nil, c.check(err)
 	}
-
 	return &MySqlResult{result}, nil
 }
 
 func (c *MySqlConn) Query(query string, args []driver.Value) (driver.Rows, error) {
+	return c.QueryContext(context.Background(), query, namedValues(args))
+}
+
+// Execute a query, the query is killed when ctx is done. Args are bound by a prepared statement,
+// which is closed with the rows. Multi-statements and stored procedures can return multiple result sets.
+func (c *MySqlConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
 	if len(args) == 0 {
-		rows, err := c.conn.QueryReader(query)
+		multi, err := c.conn.QueryMultiContext(ctx, query)
 		if err != nil {
-			return nil, err
+			return nil, c.check(err)
 		}
-		return &MySqlRows{rows}, nil
+		return newRows(multi, nil)
 	}
 
-	stmt, err1 := c.conn.Prepare(query)
-	if err1 != nil {
-		return nil, err1
+	stmt, err := c.conn.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, c.check(err)
 	}
-	defer stmt.Close()
 
-	for i := 0; i < len(args); i++ {
-		stmt.Bind(args[i])
+	if err := bindArgs(stmt, args); err != nil {
+		stmt.Close()
+		return nil, err
 	}
 
-	rows, err2 := stmt.QueryReader()
-	if err2 != nil {
-		return nil, err2
+	multi, err := stmt.QueryMultiContext(ctx)
+	if err != nil {
+		stmt.Close()
+		return nil, c.check(err)
 	}
-
-	return &MySqlRows{rows}, nil
+	return newRows(multi, stmt)
 }
 
 func (c *MySqlConn) Prepare(query string) (driver.Stmt, error) {
-	stmt, err := c.conn.Prepare(query)
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *MySqlConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	stmt, err := c.conn.PrepareContext(ctx, query)
 	if err != nil {
-		return nil, err
+		return nil, c.check(err)
 	}
-	return &MySqlStmt{*stmt}, nil
+	return &MySqlStmt{c, stmt}, nil
 }
 
 func (c *MySqlConn) Close() error {
@@ -116,29 +240,127 @@ func (c *MySqlConn) Close() error {
 }
 
 func (c *MySqlConn) Begin() (driver.Tx, error) {
-	_, err := c.conn.Execute("begin")
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// Begin a transaction with the isolation level and read-only mode.
+func (c *MySqlConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	level, err := isolationLevel(opts.Isolation)
 	if err != nil {
 		return nil, err
 	}
-	return &MySqlTx{&c.conn}, nil
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	tx, err := c.conn.Begin(mysql.TxOptions{
+		Isolation: level,
+		ReadOnly:  opts.ReadOnly,
+	})
+	if err != nil {
+		return nil, c.check(err)
+	}
+	return &MySqlTx{tx}, nil
+}
+
+// Check the connection is working. Returns driver.ErrBadConn if the connection is lost.
+func (c *MySqlConn) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := c.check(c.conn.Ping()); err != nil {
+		if c.bad {
+			return driver.ErrBadConn
+		}
+		return err
+	}
+	return nil
+}
+
+// Called by database/sql before reusing the connection. Returns driver.ErrBadConn if the connection is lost.
+func (c *MySqlConn) ResetSession(ctx context.Context) error {
+	if !c.IsValid() {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+// Called by database/sql before putting the connection back to the pool.
+func (c *MySqlConn) IsValid() bool {
+	return !c.bad && !c.conn.IsClosed()
+}
+
+// Accept the types bound natively by mysql.Stmt.Bind(), e.g. uint64 larger than math.MaxInt64
+// and time.Duration as TIME. Other types are converted by database/sql.
+func (c *MySqlConn) CheckNamedValue(nv *driver.NamedValue) error {
+	switch nv.Value.(type) {
+	case nil, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64,
+		bool, float32, float64, string, []byte, time.Time, time.Duration:
+		return nil
+	}
+	return driver.ErrSkip
+}
+
+// Mark the connection bad if err is caused by connection lost.
+func (c *MySqlConn) check(err error) error {
+	if mysql.IsConnectionLost(err) {
+		c.bad = true
+	}
+	return err
+}
+
+func isolationLevel(level driver.IsolationLevel) (mysql.IsolationLevel, error) {
+	switch sql.IsolationLevel(level) {
+	case sql.LevelDefault:
+		return mysql.IsolationDefault, nil
+	case sql.LevelReadUncommitted:
+		return mysql.IsolationReadUncommitted, nil
+	case sql.LevelReadCommitted:
+		return mysql.IsolationReadCommitted, nil
+	case sql.LevelRepeatableRead:
+		return mysql.IsolationRepeatableRead, nil
+	case sql.LevelSerializable:
+		return mysql.IsolationSerializable, nil
+	}
+	return 0, fmt.Errorf("mysql: unsupported isolation level %v", sql.IsolationLevel(level))
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	nvs := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		nvs[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return nvs
+}
+
+func bindArgs(stmt *mysql.Stmt, args []driver.NamedValue) error {
+	stmt.CleanBind()
+	for _, arg := range args {
+		if arg.Name != "" {
+			return fmt.Errorf("mysql: named parameter %q is not supported", arg.Name)
+		}
+		if err := stmt.Bind(arg.Value); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 type MySqlTx struct {
-	conn *mysql.Connection
+	tx *mysql.Tx
 }
 
 func (t *MySqlTx) Commit() error {
-	_, err := t.conn.Execute("commit")
-	return err
+	return t.tx.Commit()
 }
 
 func (t *MySqlTx) Rollback() error {
-	_, err := t.conn.Execute("rollback")
-	return err
+	return t.tx.Rollback()
 }
 
 type MySqlStmt struct {
-	stmt mysql.Stmt
+	conn *MySqlConn
+	stmt *mysql.Stmt
 }
 
 func (s *MySqlStmt) Close() error {
@@ -150,27 +372,35 @@ func (s *MySqlStmt) NumInput() int {
 }
 
 func (s *MySqlStmt) Exec(args []driver.Value) (driver.Result, error) {
-	s.stmt.CleanBind()
-	for i := 0; i < len(args); i++ {
-		s.stmt.Bind(args[i])
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+// Execute the statement, the statement is killed when ctx is done.
+func (s *MySqlStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	if err := bindArgs(s.stmt, args); err != nil {
+		return nil, err
 	}
-	result, err := s.stmt.Execute()
+	result, err := s.stmt.ExecuteContext(ctx)
 	if err != nil {
-		return nil, err
+		return nil, s.conn.check(err)
 	}
 	return &MySqlResult{result}, nil
 }
 
-func (s MySqlStmt) Query(args []driver.Value) (driver.Rows, error) {
-	s.stmt.CleanBind()
-	for i := 0; i < len(args); i++ {
-		s.stmt.Bind(args[i])
+func (s *MySqlStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+// Execute the statement as query, the statement is killed when ctx is done.
+func (s *MySqlStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	if err := bindArgs(s.stmt, args); err != nil {
+		return nil, err
 	}
-	rows, err := s.stmt.QueryReader()
+	multi, err := s.stmt.QueryMultiContext(ctx)
 	if err != nil {
-		return nil, err
+		return nil, s.conn.check(err)
 	}
-	return &MySqlRows{rows}, nil
+	return newRows(multi, nil)
 }
 
 type MySqlResult struct {
@@ -186,7 +416,30 @@ func (r *MySqlResult) RowsAffected() (int64, error) {
 }
 
 type MySqlRows struct {
-	rows mysql.DataReader
+	multi mysql.MultiResult
+	rows  mysql.DataReader // Reader of the current result set.
+	stmt  *mysql.Stmt      // Closed with the rows, nil if the rows not owns a statement.
+}
+
+func newRows(multi mysql.MultiResult, stmt *mysql.Stmt) (*MySqlRows, error) {
+	for {
+		rows, ok := multi.NextReader()
+		if !ok {
+			err := multi.Err()
+			multi.Close()
+			if stmt != nil {
+				stmt.Close()
+			}
+			if err == nil {
+				err = errors.New("mysql: query returned no result")
+			}
+			return nil, err
+		}
+		// Skip the results without result set like NextResultSet(), but keep the last one.
+		if len(rows.Fields()) != 0 || !multi.HasNext() {
+			return &MySqlRows{multi: multi, rows: rows, stmt: stmt}, nil
+		}
+	}
 }
 
 func (r *MySqlRows) Columns() []string {
@@ -200,6 +453,10 @@ func (r *MySqlRows) Columns() []string {
 
 func (r *MySqlRows) Close() error {
 	r.rows.Close()
+	r.multi.Close()
+	if r.stmt != nil {
+		return r.stmt.Close()
+	}
 	return nil
 }
 
@@ -211,8 +468,34 @@ func (r *MySqlRows) Next(dest []driver.Value) error {
 	if cols == nil {
 		return io.EOF
 	}
+	fields := r.rows.Fields()
 	for i := 0; i < len(cols); i++ {
-		dest[i] = cols[i].Interface().(driver.Value)
+		if dest[i], err = driverValue(&fields[i], &cols[i]); err != nil {
+			return err
+		}
 	}
 	return nil
 }
+
+// Check there may be more result sets, it's known after all rows of the current result set fetched.
+func (r *MySqlRows) HasNextResultSet() bool {
+	return r.multi.HasNext()
+}
+
+// Move to the next result set, results without result set (e.g. INSERT or the status of CALL) are skipped.
+// Returns io.EOF when no more result sets.
+func (r *MySqlRows) NextResultSet() error {
+	for {
+		rows, ok := r.multi.NextReader()
+		if !ok {
+			if err := r.multi.Err(); err != nil {
+				return err
+			}
+			return io.EOF
+		}
+		r.rows = rows
+		if len(rows.Fields()) != 0 {
+			return nil
+		}
+	}
+}
@@ -1,12 +1,17 @@
 package driver
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"github.com/funny/mysql"
 	"github.com/funny/utest"
 	"os"
+	"reflect"
 	"strconv"
 	"testing"
+	"time"
 )
 
 var (
@@ -152,6 +157,319 @@ func Test_Prepare(t *testing.T) {
 	stmt.Close()
 }
 
+func Test_DSN(t *testing.T) {
+	params, err := ParseDSN("root:p@ss:w/rd@tcp(127.0.0.1:3306)/mysql_test?charset=utf8mb4&timeout=5s&tls=true")
+	utest.IsNilNow(t, err)
+	utest.EqualNow(t, params.Uname, "root")
+	utest.EqualNow(t, params.Pass, "p@ss:w/rd")
+	utest.EqualNow(t, params.Host, "127.0.0.1")
+	utest.EqualNow(t, params.Port, 3306)
+	utest.EqualNow(t, params.DbName, "mysql_test")
+	utest.EqualNow(t, params.Charset, "utf8mb4")
+	utest.EqualNow(t, params.Timeout, 5*time.Second)
+	utest.EqualNow(t, params.TLS, "verify_identity")
+
+	params, err = ParseDSN("root@tcp(127.0.0.1:3306)/mysql_test?tls=skip-verify")
+	utest.IsNilNow(t, err)
+	utest.EqualNow(t, params.TLS, "required")
+
+	params, err = ParseDSN("root@tcp(localhost)/mysql_test?flags=FOUND_ROWS|client_multi_statements")
+	utest.IsNilNow(t, err)
+	utest.EqualNow(t, params.Host, "localhost")
+	utest.EqualNow(t, params.Flags, mysql.CF_CLIENT_FOUND_ROWS|mysql.CF_CLIENT_MULTI_STATEMENTS)
+
+	params, err = ParseDSN("root@unix(/tmp/mysql.sock)/mysql_test")
+	utest.IsNilNow(t, err)
+	utest.EqualNow(t, params.Uname, "root")
+	utest.EqualNow(t, params.UnixSocket, "/tmp/mysql.sock")
+	utest.EqualNow(t, params.DbName, "mysql_test")
+
+	params, err = ParseDSN("/")
+	utest.IsNilNow(t, err)
+	utest.EqualNow(t, params, mysql.ConnectionParams{})
+
+	for _, dsn := range []string{
+		"mysql_test",
+		"root@tcp(127.0.0.1:3306)/mysql_test?unknown=1",
+		"root@tcp(127.0.0.1:3306)/mysql_test?timeout=5",
+		"root@tcp(127.0.0.1:3306)/mysql_test?tls=maybe",
+		"root@udp(127.0.0.1:3306)/mysql_test",
+		"root@tcp(127.0.0.1:3306)/mysql_test?flags=FOUND_ROWS|NO_SUCH_FLAG",
+	} {
+		_, err = ParseDSN(dsn)
+		utest.Assert(t, err != nil, dsn)
+	}
+
+	for _, params := range []mysql.ConnectionParams{
+		{},
+		{Host: "127.0.0.1", Port: 3306, Uname: "root", DbName: "mysql_test"},
+		{Host: "::1", Port: 3306, Uname: "us:er", Pass: "p@ss:w/rd?&", DbName: "mysql_test"},
+		{Host: "localhost", Charset: "utf8mb4", Timeout: 1500 * time.Millisecond, TLS: "verify_identity"},
+		{UnixSocket: "/tmp/mysql.sock", Uname: "root", DedicatedThread: true, StrictWarnings: true},
+		{UnixSocket: "/tmp/my)/sql@100%.sock", Uname: "root", DbName: "mysql_test"},
+		{Host: "localhost", Flags: mysql.CF_CLIENT_COMPRESS | mysql.CF_CLIENT_LOCAL_FILES | 1<<40},
+	} {
+		dsn := FormatDSN(params)
+		params2, err := ParseDSN(dsn)
+		utest.IsNilNow(t, err)
+		utest.EqualNow(t, params2, params)
+	}
+}
+
+func Test_ClientFlags(t *testing.T) {
+	flags, err := ParseClientFlags("")
+	utest.IsNilNow(t, err)
+	utest.EqualNow(t, flags, mysql.ClientFlag(0))
+
+	flags, err = ParseClientFlags("FOUND_ROWS | CLIENT_COMPRESS|cf_client_local_files")
+	utest.IsNilNow(t, err)
+	utest.EqualNow(t, flags, mysql.CF_CLIENT_FOUND_ROWS|mysql.CF_CLIENT_COMPRESS|mysql.CF_CLIENT_LOCAL_FILES)
+	utest.EqualNow(t, FormatClientFlags(flags), "COMPRESS|FOUND_ROWS|LOCAL_FILES")
+
+	_, err = ParseClientFlags("FOUND_ROWS|FOUND_ROW")
+	utest.Assert(t, err != nil)
+
+	param := TestConnEnv
+	param.Flags = "FOUND_ROWS|MULTI_STATEMENTS"
+	name, _ := json.Marshal(param)
+
+	conn, err := sql.Open("mysql", string(name))
+	utest.IsNilNow(t, err)
+	defer conn.Close()
+
+	// FOUND_ROWS: matched rows instead of changed rows.
+	res, err := conn.Exec("UPDATE test SET value = value WHERE id = 1")
+	utest.IsNilNow(t, err)
+	num, _ := res.RowsAffected()
+	utest.EqualNow(t, num, int64(1))
+
+	param.Flags = "NO_SUCH_FLAG"
+	name, _ = json.Marshal(param)
+	conn2, err := sql.Open("mysql", string(name))
+	utest.IsNilNow(t, err)
+	defer conn2.Close()
+	utest.Assert(t, conn2.Ping() != nil)
+}
+
+func Test_Context(t *testing.T) {
+	db, err := sql.Open("mysql", TestConnParam)
+	utest.IsNilNow(t, err)
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	begin := time.Now()
+	_, err = db.ExecContext(ctx, "SELECT SLEEP(10)")
+	utest.Assert(t, errors.Is(err, context.DeadlineExceeded))
+	utest.Assert(t, time.Since(begin) < 5*time.Second)
+
+	err = db.PingContext(context.Background())
+	utest.IsNilNow(t, err)
+
+	// the statement owned by rows is not closed until the rows closed
+	rows, err := db.QueryContext(context.Background(), "SELECT id, value FROM test WHERE id < ? ORDER BY id", 3)
+	utest.IsNilNow(t, err)
+	i := 0
+	for rows.Next() {
+		var (
+			id    int64
+			value string
+		)
+		utest.IsNilNow(t, rows.Scan(&id, &value))
+		utest.EqualNow(t, id, int64(i))
+		i++
+	}
+	utest.IsNilNow(t, rows.Err())
+	utest.IsNilNow(t, rows.Close())
+	utest.EqualNow(t, i, 3)
+
+	// uint64 larger than math.MaxInt64 is bound natively
+	var u uint64
+	err = db.QueryRow("SELECT ?", uint64(1<<63)).Scan(&u)
+	utest.IsNilNow(t, err)
+	utest.EqualNow(t, u, uint64(1<<63))
+
+	_, err = db.Exec("SELECT ?", sql.Named("id", 1))
+	utest.Assert(t, err != nil)
+}
+
+func Test_BeginTx(t *testing.T) {
+	db, err := sql.Open("mysql", TestConnParam)
+	utest.IsNilNow(t, err)
+	defer db.Close()
+
+	tx, err := db.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true})
+	utest.IsNilNow(t, err)
+	_, err = tx.Exec("INSERT INTO test VALUES(100, '100')")
+	utest.Assert(t, err != nil)
+	utest.IsNilNow(t, tx.Rollback())
+
+	tx, err = db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelSerializable})
+	utest.IsNilNow(t, err)
+	var level string
+	err = tx.QueryRow("SELECT @@transaction_isolation").Scan(&level)
+	if err != nil {
+		err = tx.QueryRow("SELECT @@tx_isolation").Scan(&level)
+	}
+	utest.IsNilNow(t, err)
+	utest.EqualNow(t, level, "SERIALIZABLE")
+	utest.IsNilNow(t, tx.Rollback())
+
+	_, err = db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelSnapshot})
+	utest.Assert(t, err != nil)
+}
+
+func Test_Connector(t *testing.T) {
+	params, err := TestConnEnv.connectionParams()
+	utest.IsNilNow(t, err)
+
+	db := sql.OpenDB(NewConnector(params))
+	defer db.Close()
+
+	var n int
+	err = db.QueryRow("SELECT COUNT(*) FROM test").Scan(&n)
+	utest.IsNilNow(t, err)
+	utest.Assert(t, n > 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = NewConnector(params).Connect(ctx)
+	utest.Assert(t, err == context.Canceled)
+}
+
+func Test_ColumnTypes(t *testing.T) {
+	db, err := sql.Open("mysql", TestConnParam)
+	utest.IsNilNow(t, err)
+	defer db.Close()
+
+	rows, err := db.Query("SELECT id, value, CAST(id AS DECIMAL(10,2)) AS d, CAST(id AS UNSIGNED) AS u FROM test")
+	utest.IsNilNow(t, err)
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	utest.IsNilNow(t, err)
+	utest.EqualNow(t, len(types), 4)
+
+	utest.EqualNow(t, types[0].DatabaseTypeName(), "INT")
+	utest.EqualNow(t, types[0].ScanType(), reflect.TypeOf(int64(0)))
+	nullable, ok := types[0].Nullable()
+	utest.Assert(t, ok && !nullable)
+
+	utest.EqualNow(t, types[1].DatabaseTypeName(), "VARCHAR")
+	utest.EqualNow(t, types[1].ScanType(), reflect.TypeOf(sql.NullString{}))
+	nullable, ok = types[1].Nullable()
+	utest.Assert(t, ok && nullable)
+	length, ok := types[1].Length()
+	utest.Assert(t, ok && length >= 10)
+
+	utest.EqualNow(t, types[2].DatabaseTypeName(), "DECIMAL")
+	precision, scale, ok := types[2].DecimalSize()
+	utest.Assert(t, ok)
+	utest.EqualNow(t, precision, int64(10))
+	utest.EqualNow(t, scale, int64(2))
+	_, ok = types[0].Length()
+	utest.Assert(t, !ok)
+
+	utest.EqualNow(t, types[3].DatabaseTypeName(), "UNSIGNED BIGINT")
+	utest.EqualNow(t, types[3].ScanType(), reflect.TypeOf(uint64(0)))
+
+	// Next() returns the scan types for both the text protocol and prepared statements
+	const query = "SELECT id, value, CAST(id AS DECIMAL(10,2)), CAST(id AS UNSIGNED), CAST(value AS BINARY), CAST('01:02:03' AS TIME) FROM test WHERE id = "
+	for _, row := range []*sql.Row{
+		db.QueryRow(query + "1"),
+		db.QueryRow(query+"?", 1),
+	} {
+		var id, value, d, u, b, tm interface{}
+		utest.IsNilNow(t, row.Scan(&id, &value, &d, &u, &b, &tm))
+		utest.EqualNow(t, id, int64(1))
+		utest.EqualNow(t, value, "1")
+		utest.EqualNow(t, d, "1.00")
+		utest.EqualNow(t, u, uint64(1))
+		utest.EqualNow(t, b, []byte("1"))
+		utest.EqualNow(t, tm, "01:02:03")
+	}
+}
+
+func Test_NextResultSet(t *testing.T) {
+	param := TestConnEnv
+	param.Flags = "MULTI_STATEMENTS"
+	name, _ := json.Marshal(param)
+
+	db, err := sql.Open("mysql", string(name))
+	utest.IsNilNow(t, err)
+	defer db.Close()
+
+	rows, err := db.Query("SELECT id FROM test WHERE id < 3 ORDER BY id; UPDATE test SET value = value WHERE id < 5; SELECT 1, 2")
+	utest.IsNilNow(t, err)
+	defer rows.Close()
+
+	n := 0
+	for rows.Next() {
+		var id int
+		utest.IsNilNow(t, rows.Scan(&id))
+		utest.EqualNow(t, id, n)
+		n++
+	}
+	utest.EqualNow(t, n, 3)
+
+	// the result of UPDATE is skipped
+	utest.Assert(t, rows.NextResultSet())
+	utest.Assert(t, rows.Next())
+	var a, b int
+	utest.IsNilNow(t, rows.Scan(&a, &b))
+	utest.EqualNow(t, b, 2)
+	utest.Assert(t, !rows.Next())
+
+	utest.Assert(t, !rows.NextResultSet())
+	utest.IsNilNow(t, rows.Err())
+
+	// the leading results without result set are skipped too
+	rows, err = db.Query("UPDATE test SET value = value WHERE id < 5; SELECT id FROM test WHERE id < 3 ORDER BY id")
+	utest.IsNilNow(t, err)
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	utest.IsNilNow(t, err)
+	utest.EqualNow(t, len(cols), 1)
+	n = 0
+	for rows.Next() {
+		n++
+	}
+	utest.EqualNow(t, n, 3)
+	utest.Assert(t, !rows.NextResultSet())
+	utest.IsNilNow(t, rows.Err())
+
+	_, err = db.Exec("DROP PROCEDURE IF EXISTS test_results")
+	utest.IsNilNow(t, err)
+	_, err = db.Exec(`CREATE PROCEDURE test_results(IN n INT)
+	BEGIN
+		SELECT id FROM test WHERE id < n ORDER BY id;
+		SELECT id, value FROM test WHERE id = n;
+	END`)
+	utest.IsNilNow(t, err)
+
+	rows, err = db.Query("CALL test_results(?)", 4)
+	utest.IsNilNow(t, err)
+	defer rows.Close()
+
+	sets := 0
+	for {
+		for rows.Next() {
+		}
+		sets++
+		if !rows.NextResultSet() {
+			break
+		}
+	}
+	utest.IsNilNow(t, rows.Err())
+	utest.EqualNow(t, sets, 2)
+	rows.Close()
+
+	_, err = db.Exec("DROP PROCEDURE test_results")
+	utest.IsNilNow(t, err)
+}
+
 func Test_Clean(t *testing.T) {
 	conn, err := sql.Open("mysql", TestConnParam)
 	utest.IsNilNow(t, err)
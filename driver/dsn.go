@@ -0,0 +1,217 @@
+package driver
+
+import (
+	"fmt"
+	"github.com/funny/mysql"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Parse a data source name in the form:
+//
+//	[user[:password]@][tcp(host[:port])|unix(path)]/dbname[?param1=value1&paramN=valueN]
+//
+// Supported params: charset, timeout (e.g. "5s"), tls ("true" means "verify_identity", "skip-verify" means
+// "required", "false" means "disabled", or a TLS mode of mysql.ConnectionParams), flags (see ParseClientFlags()),
+// dedicated_thread and strict_warnings. User, password, unix socket path and param values can be URL-escaped.
+func ParseDSN(dsn string) (params mysql.ConnectionParams, err error) {
+	// The password can contain '/', '@' and ':', so split by the last '/' and the last '@'.
+	// But the unix socket path also contains '/', so the dbname starts after "unix(...)/".
+	slash := strings.LastIndex(dsn, "/")
+	if i := strings.LastIndex(dsn, "@unix("); i >= 0 || strings.HasPrefix(dsn, "unix(") {
+		end := strings.Index(dsn[i+1:], ")/")
+		if end < 0 {
+			return params, fmt.Errorf("mysql: invalid DSN %q: missing ')/' after unix socket", dsn)
+		}
+		slash = i + 1 + end + 1
+	}
+	if slash < 0 {
+		return params, fmt.Errorf("mysql: invalid DSN %q: missing the slash before dbname", dsn)
+	}
+	addr, rest := dsn[:slash], dsn[slash+1:]
+
+	if at := strings.LastIndex(addr, "@"); at >= 0 {
+		userinfo := addr[:at]
+		addr = addr[at+1:]
+
+		uname, pass, hasPass := userinfo, "", false
+		if i := strings.Index(userinfo, ":"); i >= 0 {
+			uname, pass, hasPass = userinfo[:i], userinfo[i+1:], true
+		}
+		if params.Uname, err = url.PathUnescape(uname); err != nil {
+			return params, fmt.Errorf("mysql: invalid DSN user: %w", err)
+		}
+		if hasPass {
+			if params.Pass, err = url.PathUnescape(pass); err != nil {
+				return params, fmt.Errorf("mysql: invalid DSN password: %w", err)
+			}
+		}
+	}
+
+	if err = parseDSNAddr(addr, &params); err != nil {
+		return params, err
+	}
+
+	dbname, query := rest, ""
+	if i := strings.Index(rest, "?"); i >= 0 {
+		dbname, query = rest[:i], rest[i+1:]
+	}
+	if params.DbName, err = url.PathUnescape(dbname); err != nil {
+		return params, fmt.Errorf("mysql: invalid DSN dbname: %w", err)
+	}
+
+	if query != "" {
+		values, err := url.ParseQuery(query)
+		if err != nil {
+			return params, fmt.Errorf("mysql: invalid DSN params: %w", err)
+		}
+		for key := range values {
+			if err := parseDSNParam(key, values.Get(key), &params); err != nil {
+				return params, err
+			}
+		}
+	}
+	return params, nil
+}
+
+func parseDSNAddr(addr string, params *mysql.ConnectionParams) error {
+	if addr == "" {
+		return nil
+	}
+
+	open := strings.Index(addr, "(")
+	if open < 0 || !strings.HasSuffix(addr, ")") {
+		return fmt.Errorf("mysql: invalid DSN address %q", addr)
+	}
+	network, target := addr[:open], addr[open+1:len(addr)-1]
+
+	switch network {
+	case "tcp":
+		if target == "" {
+			return nil
+		}
+		host, port, err := net.SplitHostPort(target)
+		if err != nil {
+			// No port.
+			params.Host = strings.Trim(target, "[]")
+			return nil
+		}
+		params.Host = host
+		if params.Port, err = strconv.Atoi(port); err != nil {
+			return fmt.Errorf("mysql: invalid DSN port %q", port)
+		}
+	case "unix":
+		socket, err := url.PathUnescape(target)
+		if err != nil {
+			return fmt.Errorf("mysql: invalid DSN unix socket: %w", err)
+		}
+		params.UnixSocket = socket
+	default:
+		return fmt.Errorf("mysql: unknown DSN network %q", network)
+	}
+	return nil
+}
+
+func parseDSNParam(key, value string, params *mysql.ConnectionParams) (err error) {
+	switch key {
+	case "charset":
+		params.Charset = value
+	case "timeout":
+		if params.Timeout, err = time.ParseDuration(value); err != nil {
+			return fmt.Errorf("mysql: invalid DSN timeout %q", value)
+		}
+	case "tls":
+		switch value {
+		case "true":
+			params.TLS = "verify_identity"
+		case "skip-verify":
+			params.TLS = "required"
+		case "false":
+			params.TLS = "disabled"
+		case "disabled", "preferred", "required", "verify_ca", "verify_identity":
+			params.TLS = value
+		default:
+			return fmt.Errorf("mysql: invalid DSN tls %q", value)
+		}
+	case "flags":
+		if params.Flags, err = ParseClientFlags(value); err != nil {
+			return err
+		}
+	case "dedicated_thread":
+		if params.DedicatedThread, err = strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("mysql: invalid DSN dedicated_thread %q", value)
+		}
+	case "strict_warnings":
+		if params.StrictWarnings, err = strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("mysql: invalid DSN strict_warnings %q", value)
+		}
+	default:
+		return fmt.Errorf("mysql: unknown DSN param %q", key)
+	}
+	return nil
+}
+
+// Escape the characters can break the "unix(path)" of DSN, keep '/' readable.
+var socketEscaper = strings.NewReplacer("%", "%25", ")", "%29", "@", "%40")
+
+// Format connection parameters as a data source name, which can be parsed by ParseDSN().
+func FormatDSN(params mysql.ConnectionParams) string {
+	var buf strings.Builder
+
+	if params.Uname != "" || params.Pass != "" {
+		// The first ':' separates user and password.
+		buf.WriteString(strings.Replace(url.PathEscape(params.Uname), ":", "%3A", -1))
+		if params.Pass != "" {
+			buf.WriteByte(':')
+			buf.WriteString(url.PathEscape(params.Pass))
+		}
+		buf.WriteByte('@')
+	}
+
+	if params.UnixSocket != "" {
+		buf.WriteString("unix(")
+		buf.WriteString(socketEscaper.Replace(params.UnixSocket))
+		buf.WriteByte(')')
+	} else if params.Host != "" || params.Port != 0 {
+		buf.WriteString("tcp(")
+		if params.Port != 0 {
+			buf.WriteString(net.JoinHostPort(params.Host, strconv.Itoa(params.Port)))
+		} else if strings.Contains(params.Host, ":") {
+			buf.WriteString("[" + params.Host + "]")
+		} else {
+			buf.WriteString(params.Host)
+		}
+		buf.WriteByte(')')
+	}
+
+	buf.WriteByte('/')
+	buf.WriteString(url.PathEscape(params.DbName))
+
+	values := url.Values{}
+	if params.Charset != "" {
+		values.Set("charset", params.Charset)
+	}
+	if params.Timeout != 0 {
+		values.Set("timeout", params.Timeout.String())
+	}
+	if params.TLS != "" {
+		values.Set("tls", params.TLS)
+	}
+	if params.Flags != 0 {
+		values.Set("flags", FormatClientFlags(params.Flags))
+	}
+	if params.DedicatedThread {
+		values.Set("dedicated_thread", "true")
+	}
+	if params.StrictWarnings {
+		values.Set("strict_warnings", "true")
+	}
+	if len(values) > 0 {
+		buf.WriteByte('?')
+		buf.WriteString(values.Encode())
+	}
+	return buf.String()
+}
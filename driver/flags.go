@@ -0,0 +1,71 @@
+package driver
+
+import (
+	"fmt"
+	"github.com/funny/mysql"
+	"strconv"
+	"strings"
+)
+
+// Client flag names, without the "CLIENT_" prefix.
+var clientFlags = []struct {
+	name string
+	flag mysql.ClientFlag
+}{
+	{"COMPRESS", mysql.CF_CLIENT_COMPRESS},
+	{"FOUND_ROWS", mysql.CF_CLIENT_FOUND_ROWS},
+	{"IGNORE_SIGPIPE", mysql.CF_CLIENT_IGNORE_SIGPIPE},
+	{"IGNORE_SPACE", mysql.CF_CLIENT_IGNORE_SPACE},
+	{"INTERACTIVE", mysql.CF_CLIENT_INTERACTIVE},
+	{"LOCAL_FILES", mysql.CF_CLIENT_LOCAL_FILES},
+	{"MULTI_RESULTS", mysql.CF_CLIENT_MULTI_RESULTS},
+	{"MULTI_STATEMENTS", mysql.CF_CLIENT_MULTI_STATEMENTS},
+	{"NO_SCHEMA", mysql.CF_CLIENT_NO_SCHEMA},
+	{"REMEMBER_OPTIONS", mysql.CF_CLIENT_REMEMBER_OPTIONS},
+}
+
+// Parse a client flag list like "FOUND_ROWS|MULTI_STATEMENTS".
+// Names are case-insensitive, and can have the "CLIENT_" or "CF_CLIENT_" prefix. Numbers like "0x20" are also accepted.
+func ParseClientFlags(s string) (mysql.ClientFlag, error) {
+	var flags mysql.ClientFlag
+	for _, name := range strings.Split(s, "|") {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		name = strings.TrimPrefix(strings.TrimPrefix(name, "CF_"), "CLIENT_")
+
+		if n, err := strconv.ParseInt(name, 0, 64); err == nil {
+			flags |= mysql.ClientFlag(n)
+			continue
+		}
+
+		found := false
+		for _, f := range clientFlags {
+			if f.name == name {
+				flags |= f.flag
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("mysql: unknown client flag %q in %q", name, s)
+		}
+	}
+	return flags, nil
+}
+
+// Format client flags as a list which can be parsed by ParseClientFlags().
+func FormatClientFlags(flags mysql.ClientFlag) string {
+	var names []string
+	for _, f := range clientFlags {
+		if flags&f.flag != 0 {
+			names = append(names, f.name)
+			flags &^= f.flag
+		}
+	}
+	if flags != 0 {
+		names = append(names, fmt.Sprintf("0x%x", int64(flags)))
+	}
+	return strings.Join(names, "|")
+}
@@ -0,0 +1,128 @@
+//go:build ignore
+
+// Generate errcode.go from mysqld_error.h and errmsg.h of the MySQL client library:
+//
+//	go run errcode_gen.go [-include dir] [-o errcode.go]
+//
+// The include directory defaults to the output of "mysql_config --include".
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	defineRE  = regexp.MustCompile(`^#define\s+((?:ER|CR|WARN)_[A-Z0-9_]+)\s+(\d+)\s*$`)
+	versionRE = regexp.MustCompile(`^#define\s+MYSQL_SERVER_VERSION\s+"([^"]+)"`)
+	commentRE = regexp.MustCompile(`/\*.*?\*/`)
+)
+
+type errcode struct {
+	name string
+	num  string
+}
+
+func main() {
+	include := flag.String("include", "", "include directory of the MySQL client library")
+	output := flag.String("o", "errcode.go", "output file")
+	flag.Parse()
+
+	if *include == "" {
+		out, err := exec.Command("mysql_config", "--include").Output()
+		if err != nil {
+			log.Fatalf("mysql_config: %v, use -include to specify the include directory", err)
+		}
+		*include = strings.TrimPrefix(strings.TrimSpace(string(out)), "-I")
+	}
+
+	server, err := parseHeader(filepath.Join(*include, "mysqld_error.h"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	client, err := parseHeader(filepath.Join(*include, "errmsg.h"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	from := "mysqld_error.h and errmsg.h"
+	if version := serverVersion(filepath.Join(*include, "mysql_version.h")); version != "" {
+		from += " of MySQL " + version
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by errcode_gen.go from %s; DO NOT EDIT.\n\n", from)
+	buf.WriteString("package mysql\n\n")
+	writeConsts(&buf, "Server error codes, see mysqld_error.h and\n// http://dev.mysql.com/doc/refman/5.7/en/error-messages-server.html", server)
+	buf.WriteString("\n")
+	writeConsts(&buf, "Client error codes, see errmsg.h and\n// http://dev.mysql.com/doc/refman/5.7/en/error-messages-client.html", client)
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*output, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Parse the "#define NAME NUMBER" lines, except the range markers like ER_ERROR_FIRST and CR_MAX_ERROR.
+func parseHeader(path string) ([]errcode, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var codes []errcode
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := commentRE.ReplaceAllString(scanner.Text(), "")
+		m := defineRE.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		name := m[1]
+		if strings.HasSuffix(name, "_FIRST") || strings.HasSuffix(name, "_LAST") || name == "CR_MIN_ERROR" || name == "CR_MAX_ERROR" {
+			continue
+		}
+		codes = append(codes, errcode{name, m[2]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(codes) == 0 {
+		return nil, fmt.Errorf("no error code found in %s", path)
+	}
+	return codes, nil
+}
+
+func serverVersion(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if m := versionRE.FindStringSubmatch(line); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+func writeConsts(buf *bytes.Buffer, doc string, codes []errcode) {
+	fmt.Fprintf(buf, "// %s\nconst (\n", doc)
+	for _, code := range codes {
+		fmt.Fprintf(buf, "\t%s = %s\n", code.name, code.num)
+	}
+	buf.WriteString(")\n")
+}
@@ -4,58 +4,221 @@ package mysql
 #include "cgo.h"
 */
 import "C"
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
-// SQL error.
-type SqlError struct {
-	Num     int
-	Message string
-	Query   string
+// Error with a MySQL error number. MySQLError, SqlError and StmtError implement it.
+type NumberedError interface {
+	error
+
+	// Get error number.
+	Number() int
+}
+
+// MySQL error. SqlError and StmtError are unwrapped to it, so errors.As(err, &mysqlErr) works for both.
+type MySQLError struct {
+	Num      int    // Error number, see the ER_XXX and CR_XXX constants.
+	SQLState string // Five-character SQLSTATE error code, e.g. "23000".
+	Message  string // Error message.
+	Query    string // The query caused the error, maybe empty.
+	Op       string // The operation failed, e.g. "connect", "execute", "query", "fetch".
+	ConnId   int64  // Thread id of the connection, 0 if unknown.
 }
 
 // Get error string.
-func (se *SqlError) Error() string {
-	if se.Query == "" {
-		return fmt.Sprintf("%v (errno %v)", se.Message, se.Num)
+func (e *MySQLError) Error() string {
+	if e.Query == "" {
+		return fmt.Sprintf("%v (errno %v)", e.Message, e.Num)
 	}
-	return fmt.Sprintf("%v (errno %v) during query: %s", se.Message, se.Num, se.Query)
+	return fmt.Sprintf("%v (errno %v) during query: %s", e.Message, e.Num, e.Query)
 }
 
 // Get error number.
-func (se *SqlError) Number() int {
-	return se.Num
+func (e *MySQLError) Number() int {
+	return e.Num
+}
+
+// Is reports the error belongs to the class of target, so errors.Is(err, ErrDuplicateKey) works.
+func (e *MySQLError) Is(target error) bool {
+	return isErrorClass(e.Num, target)
+}
+
+// SQL error. Returned by the methods of Connection.
+type SqlError struct {
+	MySQLError
+}
+
+// Unwrap returns the MySQLError.
+func (se *SqlError) Unwrap() error {
+	return &se.MySQLError
+}
+
+// Statement error. Returned by the methods of Stmt and its results.
+type StmtError struct {
+	MySQLError
+}
+
+// Unwrap returns the MySQLError.
+func (se *StmtError) Unwrap() error {
+	return &se.MySQLError
 }
 
-func (conn *Connection) lastError(query string) error {
+func (conn *Connection) lastError(op, query string) error {
+	e := MySQLError{Num: 0, Message: "Unknow", Query: query, Op: op, ConnId: conn.threadId()}
 	if err := C.my_error(&conn.c); *err != 0 {
-		return &SqlError{Num: int(C.my_errno(&conn.c)), Message: C.GoString(err), Query: query}
+		conn.errno = int(C.my_errno(&conn.c))
+		e.Num = conn.errno
+		e.SQLState = C.GoString(C.my_sqlstate(&conn.c))
+		e.Message = C.GoString(err)
 	}
-	return &SqlError{0, "Unknow", string(query)}
+	return &SqlError{e}
 }
 
-// Statement error.
-type StmtError struct {
-	Num     int
-	Message string
-	Stmt    *Stmt
+func (conn *Connection) closedError(op, query string) error {
+	return &SqlError{closedError(op, query)}
+}
+
+func (stmt *Stmt) lastError(op string) error {
+	e := MySQLError{Num: 0, Message: "Unknow", Query: stmt.sql, Op: op, ConnId: stmt.conn.threadId()}
+	if err := C.my_stmt_error(stmt.s); *err != 0 {
+		stmt.conn.errno = int(C.my_stmt_errno(stmt.s))
+		e.Num = stmt.conn.errno
+		e.SQLState = C.GoString(C.my_stmt_sqlstate(stmt.s))
+		e.Message = C.GoString(err)
+	}
+	return &StmtError{e}
+}
+
+func (stmt *Stmt) closedError(op string) error {
+	return &StmtError{closedError(op, stmt.sql)}
+}
+
+func closedError(op, query string) MySQLError {
+	return MySQLError{Num: CR_SERVER_GONE_ERROR, SQLState: "HY000", Message: "Connection is closed", Query: query, Op: op}
+}
+
+var (
+	// The value is NULL.
+	ErrNullValue = errors.New("value is NULL")
+
+	// The value can't be converted to the target type.
+	ErrTypeMismatch = errors.New("type mismatch")
+)
+
+// Value conversion error. Returned by the TryXxx() methods of Value.
+type ConvertError struct {
+	Type   TypeCode // The column type.
+	Target string   // The target Go type.
+	Value  string   // The text of the value. Empty for binary values.
+	Err    error    // ErrNullValue, ErrTypeMismatch, strconv.ErrSyntax or strconv.ErrRange.
 }
 
 // Get error string.
-func (self *StmtError) Error() string {
-	if len(self.Stmt.sql) == 0 {
-		return fmt.Sprintf("%v (errno %v)", self.Message, self.Num)
+func (ce *ConvertError) Error() string {
+	if ce.Value == "" {
+		return fmt.Sprintf("mysql: can't convert %v value to %s: %v", ce.Type, ce.Target, ce.Err)
 	}
-	return fmt.Sprintf("%v (errno %v) during query: %s", self.Message, self.Num, self.Stmt.sql)
+	return fmt.Sprintf("mysql: can't convert %v value %q to %s: %v", ce.Type, ce.Value, ce.Target, ce.Err)
 }
 
-// Get error number.
-func (self *StmtError) Number() int {
-	return self.Num
+// Unwrap returns the cause, so errors.Is(err, ErrNullValue) works.
+func (ce *ConvertError) Unwrap() error {
+	return ce.Err
 }
 
-func (stmt *Stmt) lastError() error {
-	if err := C.my_stmt_error(stmt.s); *err != 0 {
-		return &StmtError{Num: int(C.my_stmt_errno(stmt.s)), Message: C.GoString(C.my_stmt_error(stmt.s)), Stmt: stmt}
+// Context error. Returned by the XxxContext() methods when the context is done before the query finished.
+type ContextError struct {
+	Err   error // The context error, context.Canceled or context.DeadlineExceeded.
+	Cause error // The error returned by the killed query and the KILL QUERY, maybe nil.
+}
+
+// Get error string.
+func (ce *ContextError) Error() string {
+	if ce.Cause == nil {
+		return ce.Err.Error()
+	}
+	return fmt.Sprintf("%v: %v", ce.Err, ce.Cause)
+}
+
+// Unwrap returns both the context error and the query error, so errors.Is(err, context.Canceled) works.
+func (ce *ContextError) Unwrap() []error {
+	if ce.Cause == nil {
+		return []error{ce.Err}
+	}
+	return []error{ce.Err, ce.Cause}
+}
+
+// The error code constants in errcode.go are generated from the headers of the MySQL client library.
+//go:generate go run errcode_gen.go
+
+// Error classes, use errors.Is(err, ErrXxx) or the IsXxx(err) functions to check SqlError and StmtError.
+var (
+	ErrDuplicateKey    = errors.New("mysql: duplicate key")
+	ErrDeadlock        = errors.New("mysql: deadlock")
+	ErrLockWaitTimeout = errors.New("mysql: lock wait timeout")
+	ErrConnectionLost  = errors.New("mysql: connection lost")
+	ErrReadOnly        = errors.New("mysql: read only")
+)
+
+func isErrorClass(num int, target error) bool {
+	switch target {
+	case ErrDuplicateKey:
+		return num == ER_DUP_ENTRY || num == ER_DUP_ENTRY_WITH_KEY_NAME || num == ER_DUP_KEY || num == ER_DUP_UNIQUE
+	case ErrDeadlock:
+		return num == ER_LOCK_DEADLOCK || num == ER_XA_RBDEADLOCK
+	case ErrLockWaitTimeout:
+		return num == ER_LOCK_WAIT_TIMEOUT || num == ER_XA_RBTIMEOUT
+	case ErrConnectionLost:
+		return num == CR_SERVER_GONE_ERROR || num == CR_SERVER_LOST || num == CR_SERVER_LOST_EXTENDED
+	case ErrReadOnly:
+		return num == ER_OPTION_PREVENTS_STATEMENT || num == ER_READ_ONLY_MODE ||
+			num == ER_READ_ONLY_TRANSACTION || num == ER_CANT_EXECUTE_IN_READ_ONLY_TRANSACTION
+	}
+	return false
+}
+
+// Get the error number of NumberedError in the error chain.
+func errorNumber(err error) (int, bool) {
+	var numErr NumberedError
+	if !errors.As(err, &numErr) {
+		return 0, false
 	}
-	return &StmtError{0, "Unknow", stmt}
+	return numErr.Number(), true
+}
+
+func isErrorNumberClass(err error, target error) bool {
+	num, ok := errorNumber(err)
+	return ok && isErrorClass(num, target)
+}
+
+// Check the error is caused by duplicate entry of a unique key.
+func IsDuplicateKey(err error) bool {
+	return isErrorNumberClass(err, ErrDuplicateKey)
+}
+
+// Check the error is caused by deadlock, the transaction has been rolled back.
+func IsDeadlock(err error) bool {
+	return isErrorNumberClass(err, ErrDeadlock)
+}
+
+// Check the error is caused by lock wait timeout, or the XA branch rolled back by timeout.
+func IsLockWaitTimeout(err error) bool {
+	return isErrorNumberClass(err, ErrLockWaitTimeout)
+}
+
+// Check the connection to the server is lost.
+func IsConnectionLost(err error) bool {
+	return isErrorNumberClass(err, ErrConnectionLost)
+}
+
+// Check the transaction can be retried, the error is caused by deadlock or lock wait timeout.
+func IsRetryable(err error) bool {
+	return IsDeadlock(err) || IsLockWaitTimeout(err)
+}
+
+// Check the error is caused by writing to a read only server or transaction.
+func IsReadOnly(err error) bool {
+	return isErrorNumberClass(err, ErrReadOnly)
 }
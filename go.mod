@@ -0,0 +1,5 @@
+module github.com/funny/mysql
+
+go 1.23
+
+require github.com/funny/utest master
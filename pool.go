@@ -0,0 +1,358 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Returned by Pool.Get() after the pool closed.
+var ErrPoolClosed = errors.New("mysql: pool is closed")
+
+// Connection pool settings. Zero value means no limit.
+type PoolSettings struct {
+	MaxOpen     int           // Maximum number of open connections, include idle and in use.
+	MaxIdle     int           // Maximum number of idle connections.
+	MaxLifetime time.Duration // Maximum amount of time a connection may be reused.
+	IdleTimeout time.Duration // Maximum amount of time a connection may be idle.
+}
+
+// Connection pool statistics.
+type PoolStats struct {
+	MaxOpen           int           // Maximum number of open connections.
+	Open              int           // The number of established connections both in use and idle.
+	InUse             int           // The number of connections currently in use.
+	Idle              int           // The number of idle connections.
+	WaitCount         int64         // The total number of connections waited for.
+	WaitDuration      time.Duration // The total time blocked waiting for a new connection.
+	MaxIdleClosed     int64         // The total number of connections closed due to MaxIdle.
+	MaxLifetimeClosed int64         // The total number of connections closed due to MaxLifetime.
+	IdleTimeoutClosed int64         // The total number of connections closed due to IdleTimeout.
+	BrokenClosed      int64         // The total number of connections closed due to broken link or failed validation.
+}
+
+// Connection pool on top of Connect().
+type Pool struct {
+	params   ConnectionParams
+	settings PoolSettings
+
+	mutex   sync.Mutex
+	closed  bool
+	idle    []idleConn
+	born    map[*Connection]time.Time
+	inUse   map[*Connection]bool
+	numOpen int
+	waiters []chan *Connection
+	stats   PoolStats
+	stop    chan struct{}
+}
+
+type idleConn struct {
+	conn  *Connection
+	since time.Time
+}
+
+// Create a connection pool. Connections are established lazily by Get().
+// Idle connections are closed in background when IdleTimeout or MaxLifetime is set.
+func NewPool(params ConnectionParams, settings PoolSettings) *Pool {
+	pool := &Pool{
+		params:   params,
+		settings: settings,
+		born:     make(map[*Connection]time.Time),
+		inUse:    make(map[*Connection]bool),
+		stop:     make(chan struct{}),
+	}
+	if interval := cleanInterval(settings); interval > 0 {
+		go pool.cleaner(interval)
+	}
+	return pool
+}
+
+// Get a connection from the pool. Blocks until a connection is available,
+// the pool is closed or ctx is done. NOTE: Please remember put the connection back.
+func (pool *Pool) Get(ctx context.Context) (*Connection, error) {
+	for {
+		pool.mutex.Lock()
+		if pool.closed {
+			pool.mutex.Unlock()
+			return nil, ErrPoolClosed
+		}
+
+		// Reuse an idle connection.
+		if n := len(pool.idle); n > 0 {
+			ic := pool.idle[n-1]
+			pool.idle = pool.idle[:n-1]
+			if pool.expired(ic, time.Now()) {
+				pool.discard(ic.conn)
+				pool.mutex.Unlock()
+				continue
+			}
+			pool.mutex.Unlock()
+
+			if ic.conn.Ping() != nil {
+				pool.mutex.Lock()
+				pool.stats.BrokenClosed++
+				pool.discard(ic.conn)
+				pool.mutex.Unlock()
+				continue
+			}
+			pool.mutex.Lock()
+			pool.inUse[ic.conn] = true
+			pool.mutex.Unlock()
+			return ic.conn, nil
+		}
+
+		// Open a new connection.
+		if pool.settings.MaxOpen <= 0 || pool.numOpen < pool.settings.MaxOpen {
+			pool.numOpen++
+			pool.mutex.Unlock()
+			return pool.open()
+		}
+
+		// Wait for a connection put back or a slot released.
+		req := make(chan *Connection, 1)
+		pool.waiters = append(pool.waiters, req)
+		pool.stats.WaitCount++
+		pool.mutex.Unlock()
+
+		begin := time.Now()
+		select {
+		case conn, ok := <-req:
+			pool.addWaitDuration(time.Since(begin))
+			if !ok {
+				return nil, ErrPoolClosed
+			}
+			if conn == nil {
+				// A slot was released for us.
+				return pool.open()
+			}
+			if conn.Ping() != nil {
+				pool.mutex.Lock()
+				pool.stats.BrokenClosed++
+				pool.discard(conn)
+				pool.mutex.Unlock()
+				continue
+			}
+			return conn, nil
+		case <-ctx.Done():
+			pool.addWaitDuration(time.Since(begin))
+			pool.mutex.Lock()
+			removed := pool.removeWaiter(req)
+			pool.mutex.Unlock()
+			if !removed {
+				// Someone has handed us a connection or a slot, give it back.
+				if conn, ok := <-req; ok {
+					if conn == nil {
+						pool.mutex.Lock()
+						pool.release()
+						pool.mutex.Unlock()
+					} else {
+						pool.Put(conn)
+					}
+				}
+			}
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Put a connection back to the pool. Closed and broken connections will be discarded.
+// Put a connection which is already put back has no effect.
+func (pool *Pool) Put(conn *Connection) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	if _, ok := pool.born[conn]; !ok {
+		conn.Close()
+		return
+	}
+
+	if !pool.inUse[conn] {
+		return
+	}
+
+	if pool.closed || conn.IsClosed() {
+		pool.discard(conn)
+		return
+	}
+
+	if isBrokenLink(conn.errno) {
+		pool.stats.BrokenClosed++
+		pool.discard(conn)
+		return
+	}
+
+	now := time.Now()
+	if pool.settings.MaxLifetime > 0 && now.Sub(pool.born[conn]) >= pool.settings.MaxLifetime {
+		pool.stats.MaxLifetimeClosed++
+		pool.discard(conn)
+		return
+	}
+
+	if len(pool.waiters) > 0 {
+		req := pool.waiters[0]
+		pool.waiters = pool.waiters[1:]
+		req <- conn
+		return
+	}
+
+	if pool.settings.MaxIdle > 0 && len(pool.idle) >= pool.settings.MaxIdle {
+		pool.stats.MaxIdleClosed++
+		pool.discard(conn)
+		return
+	}
+
+	delete(pool.inUse, conn)
+	pool.idle = append(pool.idle, idleConn{conn, now})
+}
+
+// Get a snapshot of the pool statistics.
+func (pool *Pool) Stats() PoolStats {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	stats := pool.stats
+	stats.MaxOpen = pool.settings.MaxOpen
+	stats.Open = pool.numOpen
+	stats.Idle = len(pool.idle)
+	stats.InUse = len(pool.inUse)
+	return stats
+}
+
+// Close the pool and all idle connections. Connections in use will be closed when they put back.
+func (pool *Pool) Close() {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	if pool.closed {
+		return
+	}
+	pool.closed = true
+	close(pool.stop)
+
+	for _, ic := range pool.idle {
+		pool.discard(ic.conn)
+	}
+	pool.idle = nil
+
+	for _, req := range pool.waiters {
+		close(req)
+	}
+	pool.waiters = nil
+}
+
+func (pool *Pool) open() (*Connection, error) {
+	conn, err := Connect(pool.params)
+
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	if err == nil && pool.closed {
+		conn.Close()
+		err = ErrPoolClosed
+	}
+	if err != nil {
+		pool.release()
+		return nil, err
+	}
+	pool.born[conn] = time.Now()
+	pool.inUse[conn] = true
+	return conn, nil
+}
+
+// Close a connection belong to the pool and release its slot. Must hold the mutex.
+func (pool *Pool) discard(conn *Connection) {
+	conn.Close()
+	delete(pool.born, conn)
+	delete(pool.inUse, conn)
+	pool.release()
+}
+
+// Close the expired idle connections periodically, until the pool closed.
+func (pool *Pool) cleaner(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-pool.stop:
+			return
+		}
+
+		pool.mutex.Lock()
+		now := time.Now()
+		idle := pool.idle[:0]
+		for _, ic := range pool.idle {
+			if pool.expired(ic, now) {
+				pool.discard(ic.conn)
+			} else {
+				idle = append(idle, ic)
+			}
+		}
+		pool.idle = idle
+		pool.mutex.Unlock()
+	}
+}
+
+// The smaller one of IdleTimeout and MaxLifetime, but not less than one second. Zero means no cleaner.
+func cleanInterval(settings PoolSettings) time.Duration {
+	interval := settings.IdleTimeout
+	if settings.MaxLifetime > 0 && (interval <= 0 || settings.MaxLifetime < interval) {
+		interval = settings.MaxLifetime
+	}
+	if interval <= 0 {
+		return 0
+	}
+	if interval < time.Second {
+		interval = time.Second
+	}
+	return interval
+}
+
+// Release an open slot, hand it to the first waiter if any. Must hold the mutex.
+func (pool *Pool) release() {
+	if len(pool.waiters) > 0 {
+		req := pool.waiters[0]
+		pool.waiters = pool.waiters[1:]
+		req <- nil
+		return
+	}
+	pool.numOpen--
+}
+
+// Check an idle connection is expired or not. Must hold the mutex.
+func (pool *Pool) expired(ic idleConn, now time.Time) bool {
+	if pool.settings.MaxLifetime > 0 && now.Sub(pool.born[ic.conn]) >= pool.settings.MaxLifetime {
+		pool.stats.MaxLifetimeClosed++
+		return true
+	}
+	if pool.settings.IdleTimeout > 0 && now.Sub(ic.since) >= pool.settings.IdleTimeout {
+		pool.stats.IdleTimeoutClosed++
+		return true
+	}
+	return false
+}
+
+// Must hold the mutex.
+func (pool *Pool) removeWaiter(req chan *Connection) bool {
+	for i, w := range pool.waiters {
+		if w == req {
+			pool.waiters = append(pool.waiters[:i], pool.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (pool *Pool) addWaitDuration(d time.Duration) {
+	pool.mutex.Lock()
+	pool.stats.WaitDuration += d
+	pool.mutex.Unlock()
+}
+
+// Error 2006 (MySQL server has gone away), 2013 (Lost connection to MySQL server during query) and 2055.
+func isBrokenLink(errno int) bool {
+	return isErrorClass(errno, ErrConnectionLost)
+}
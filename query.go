@@ -0,0 +1,126 @@
+package mysql
+
+import (
+	"errors"
+	"iter"
+	"reflect"
+)
+
+// Returned by QueryOne() when the query returns no rows.
+var ErrNoRows = errors.New("mysql: no rows in result set")
+
+// Execute a query and scan all the rows into a slice of T.
+// T can be a struct or pointer to struct, columns are mapped to struct fields like DataTable.ScanAll().
+// Otherwise the query must return one column, and T can be any type supported by ScanRow().
+// When args not empty the query is prepared and args are bound by Stmt.Bind().
+func QueryAll[T any](conn *Connection, sql string, args ...interface{}) ([]T, error) {
+	var result []T
+	for v, err := range QueryIter[T](conn, sql, args...) {
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, v)
+	}
+	return result, nil
+}
+
+// Execute a query and scan the first row into T, returns ErrNoRows if no rows. See QueryAll().
+func QueryOne[T any](conn *Connection, sql string, args ...interface{}) (T, error) {
+	for v, err := range QueryIter[T](conn, sql, args...) {
+		return v, err
+	}
+	var zero T
+	return zero, ErrNoRows
+}
+
+// Execute a query when iterating, and yield the rows one by one. See QueryAll().
+// The reader is closed when the iteration finished or stopped, an error stops the iteration.
+func QueryIter[T any](conn *Connection, sql string, args ...interface{}) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		reader, closeReader, err := openReader(conn, sql, args)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer closeReader()
+
+		scan := rowScanner[T]()
+		for {
+			row, err := reader.FetchNext()
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			if row == nil {
+				return
+			}
+			v, err := scan(reader)
+			if !yield(v, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Open a reader by Connection.QueryReader(), or Stmt.QueryReader() if args not empty.
+func openReader(conn *Connection, sql string, args []interface{}) (DataReader, func(), error) {
+	if len(args) == 0 {
+		reader, err := conn.QueryReader(sql)
+		if err != nil {
+			return nil, nil, err
+		}
+		return reader, reader.Close, nil
+	}
+
+	stmt, err := conn.Prepare(sql)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, arg := range args {
+		if err := stmt.Bind(arg); err != nil {
+			stmt.Close()
+			return nil, nil, err
+		}
+	}
+	reader, err := stmt.QueryReader()
+	if err != nil {
+		stmt.Close()
+		return nil, nil, err
+	}
+	return reader, func() {
+		reader.Close()
+		stmt.Close()
+	}, nil
+}
+
+// Get the function to scan the current row of reader into T.
+func rowScanner[T any]() func(DataReader) (T, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	switch {
+	case isStructDest(t):
+		return func(reader DataReader) (v T, err error) {
+			err = reader.ScanStruct(&v)
+			return
+		}
+	case t.Kind() == reflect.Ptr && isStructDest(t.Elem()):
+		return func(reader DataReader) (v T, err error) {
+			p := reflect.New(t.Elem())
+			if err = reader.ScanStruct(p.Interface()); err != nil {
+				return
+			}
+			return p.Interface().(T), nil
+		}
+	}
+	return func(reader DataReader) (v T, err error) {
+		err = reader.Scan(&v)
+		return
+	}
+}
+
+// Check t is a struct mapped by fields, time.Time and sql.Scanner are scanned as a single column.
+func isStructDest(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != timeType && !reflect.PointerTo(t).Implements(scannerType)
+}
@@ -0,0 +1,66 @@
+package mysql
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Retry policy of transactions failed by deadlock (error 1213) or lock wait timeout (error 1205).
+// Zero value fields use the default settings.
+type RetryPolicy struct {
+	MaxAttempts int           // Maximum number of attempts, include the first one. Default 3.
+	BaseDelay   time.Duration // Delay before the first retry. Default 10ms.
+	MaxDelay    time.Duration // Maximum delay between retries. Default 1s.
+	Multiplier  float64       // Delay grows by the multiplier after each retry. Default 2.
+
+	// Called before each retry, attempt is the number of the failed attempt.
+	OnRetry func(attempt int, err error, delay time.Duration)
+}
+
+func (policy *RetryPolicy) setDefaults() {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 3
+	}
+	if policy.BaseDelay <= 0 {
+		policy.BaseDelay = 10 * time.Millisecond
+	}
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = time.Second
+	}
+	if policy.Multiplier < 1 {
+		policy.Multiplier = 2
+	}
+}
+
+// Run f until it succeeds, returns an error other than deadlock and lock wait timeout, or the attempts exhausted.
+// Delays between attempts grow exponentially and are randomized in [delay/2, delay] to avoid retrying together.
+// Returns the number of attempts and the last error. NOTE: f must be safe to replay from the beginning.
+func Retry(policy RetryPolicy, f func() error) (attempts int, err error) {
+	policy.setDefaults()
+
+	delay := policy.BaseDelay
+	for attempts = 1; ; attempts++ {
+		err = f()
+		if err == nil || !IsRetryable(err) || attempts >= policy.MaxAttempts {
+			return attempts, err
+		}
+
+		d := delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+		if policy.OnRetry != nil {
+			policy.OnRetry(attempts, err, d)
+		}
+		time.Sleep(d)
+
+		delay = time.Duration(float64(delay) * policy.Multiplier)
+		if delay > policy.MaxDelay {
+			delay = policy.MaxDelay
+		}
+	}
+}
+
+// Run f in a transaction by RunInTx(), replay the whole transaction on deadlock or lock wait timeout by Retry().
+func (conn *Connection) RunInTxRetry(opts TxOptions, policy RetryPolicy, f func(*Tx) error) (attempts int, err error) {
+	return Retry(policy, func() error {
+		return conn.RunInTx(opts, f)
+	})
+}
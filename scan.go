@@ -0,0 +1,341 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+var (
+	// Returned by DataReader.Scan() and DataReader.ScanStruct() when FetchNext() not called or no more rows.
+	ErrNoRow = errors.New("mysql: no current row, call FetchNext() first")
+
+	scannerType  = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// Reflection plan of a struct type for a list of fields.
+// plan[i] is the struct field index path of column i, nil if no field for the column.
+type structPlan [][]int
+
+type structPlanKey struct {
+	typ    reflect.Type
+	fields string
+}
+
+// Cache of structPlan, key is structPlanKey.
+var structPlans sync.Map
+
+// Get the reflection plan of t for fields, t must be a struct type.
+func getStructPlan(t reflect.Type, fields []Field) structPlan {
+	names := make([]string, len(fields))
+	for i := range fields {
+		names[i] = fields[i].Name
+	}
+	key := structPlanKey{t, strings.Join(names, "\x00")}
+
+	if plan, ok := structPlans.Load(key); ok {
+		return plan.(structPlan)
+	}
+
+	columns := make(map[string][]int)
+	walkStruct(t, nil, columns)
+
+	plan := make(structPlan, len(fields))
+	for i, name := range names {
+		plan[i] = columns[name]
+	}
+
+	structPlans.Store(key, plan)
+	return plan
+}
+
+// Collect the column names of struct fields. Outer fields hide the embedded ones, like encoding/json.
+func walkStruct(t reflect.Type, index []int, columns map[string][]int) {
+	var embedded []reflect.StructField
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		tag := f.Tag.Get("mysql")
+		if tag == "-" {
+			continue
+		}
+
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && tag == "" && ft.Kind() == reflect.Struct && ft != timeType {
+			// Can't allocate the embedded pointer of unexported struct type.
+			if f.PkgPath == "" || f.Type.Kind() != reflect.Ptr {
+				embedded = append(embedded, f)
+			}
+			continue
+		}
+
+		if f.PkgPath != "" {
+			// unexported
+			continue
+		}
+
+		name := tag
+		if name == "" {
+			name = snakeCase(f.Name)
+		}
+		if _, ok := columns[name]; !ok {
+			columns[name] = append(append([]int(nil), index...), i)
+		}
+	}
+
+	for _, f := range embedded {
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		sub := make(map[string][]int)
+		walkStruct(ft, append(append([]int(nil), index...), f.Index...), sub)
+		for name, path := range sub {
+			if _, ok := columns[name]; !ok {
+				columns[name] = path
+			}
+		}
+	}
+}
+
+// Convert CamelCase field name to snake_case column name, e.g. "UserID" to "user_id".
+func snakeCase(name string) string {
+	runes := []rune(name)
+	buf := make([]rune, 0, len(runes)+4)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				buf = append(buf, '_')
+			}
+			r = unicode.ToLower(r)
+		}
+		buf = append(buf, r)
+	}
+	return string(buf)
+}
+
+// Scan a row of DataTable into the values pointed by dest, the number of dest must be the same as the columns.
+// dest can be pointer to integer, float, string, []byte, bool, time.Time, time.Duration, interface{},
+// or sql.Scanner. Use pointer to pointer (e.g. **string) to receive NULL.
+func ScanRow(row []Value, dest ...interface{}) error {
+	if row == nil {
+		return ErrNoRow
+	}
+	if len(dest) != len(row) {
+		return fmt.Errorf("mysql: expected %d destination arguments in Scan, not %d", len(row), len(dest))
+	}
+	for i, d := range dest {
+		if err := scanValue(&row[i], d); err != nil {
+			return fmt.Errorf("mysql: scan column %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Scan value into the variable pointed by dst.
+func scanValue(v *Value, dst interface{}) error {
+	if scanner, ok := dst.(sql.Scanner); ok {
+		return scanner.Scan(v.Interface())
+	}
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("mysql: scan destination must be a non-nil pointer, got %T", dst)
+	}
+	return assignValue(rv.Elem(), v)
+}
+
+// Scan row into the struct pointed by dst.
+func scanRowStruct(fields []Field, row []Value, dst interface{}) error {
+	if row == nil {
+		return ErrNoRow
+	}
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("mysql: scan destination must be a non-nil pointer to struct, got %T", dst)
+	}
+	sv := v.Elem()
+	return scanStruct(getStructPlan(sv.Type(), fields), fields, row, sv)
+}
+
+// Scan row into struct value by plan.
+func scanStruct(plan structPlan, fields []Field, row []Value, sv reflect.Value) error {
+	for i, path := range plan {
+		if path == nil {
+			continue
+		}
+		if err := assignValue(fieldByIndex(sv, path), &row[i]); err != nil {
+			return fmt.Errorf("mysql: scan column %q: %w", fields[i].Name, err)
+		}
+	}
+	return nil
+}
+
+// Like reflect.Value.FieldByIndex, but allocate nil embedded pointers.
+func fieldByIndex(v reflect.Value, path []int) reflect.Value {
+	for i, x := range path {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// Scan rows into the slice pointed by dst, the slice element can be struct or pointer to struct.
+func scanAll(fields []Field, rows [][]Value, dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("mysql: scan destination must be a non-nil pointer to slice, got %T", dst)
+	}
+	slice := v.Elem()
+
+	elemType := slice.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	structType := elemType
+	if isPtr {
+		structType = elemType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("mysql: scan destination must be a slice of struct, got %T", dst)
+	}
+
+	plan := getStructPlan(structType, fields)
+	result := reflect.MakeSlice(slice.Type(), 0, len(rows))
+	for _, row := range rows {
+		elem := reflect.New(structType)
+		if err := scanStruct(plan, fields, row, elem.Elem()); err != nil {
+			return err
+		}
+		if isPtr {
+			result = reflect.Append(result, elem)
+		} else {
+			result = reflect.Append(result, elem.Elem())
+		}
+	}
+	slice.Set(result)
+	return nil
+}
+
+// Assign value to dst with conversion.
+func assignValue(dst reflect.Value, v *Value) error {
+	if dst.CanAddr() && dst.Addr().Type().Implements(scannerType) {
+		return dst.Addr().Interface().(sql.Scanner).Scan(v.Interface())
+	}
+
+	if dst.Kind() == reflect.Ptr {
+		if v.IsNull() {
+			dst.Set(reflect.Zero(dst.Type()))
+			return nil
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return assignValue(dst.Elem(), v)
+	}
+
+	if dst.Kind() == reflect.Interface {
+		if v.IsNull() {
+			dst.Set(reflect.Zero(dst.Type()))
+			return nil
+		}
+		// e.g. a fmt.Stringer can't hold int64.
+		r := reflect.ValueOf(v.Interface())
+		if !r.Type().AssignableTo(dst.Type()) {
+			return v.convertError(dst.Type().String(), ErrTypeMismatch)
+		}
+		dst.Set(r)
+		return nil
+	}
+
+	target := dst.Type().String()
+	if v.IsNull() {
+		return v.convertError(target, ErrNullValue)
+	}
+
+	switch dst.Type() {
+	case timeType:
+		t, err := v.Time(time.UTC)
+		if err != nil {
+			return err
+		}
+		dst.Set(reflect.ValueOf(t))
+		return nil
+	case durationType:
+		if v.Type == MYSQL_TYPE_TIME {
+			d, err := v.Duration()
+			if err != nil {
+				return err
+			}
+			dst.SetInt(int64(d))
+			return nil
+		}
+	}
+
+	switch dst.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		r, err := v.toInt(target, math.MinInt64, math.MaxInt64)
+		if err != nil {
+			return err
+		}
+		if dst.OverflowInt(r) {
+			return v.convertError(target, strconv.ErrRange)
+		}
+		dst.SetInt(r)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		r, err := v.toUint(target, math.MaxUint64)
+		if err != nil {
+			return err
+		}
+		if dst.OverflowUint(r) {
+			return v.convertError(target, strconv.ErrRange)
+		}
+		dst.SetUint(r)
+	case reflect.Float32, reflect.Float64:
+		r, err := v.toFloat(target)
+		if err != nil {
+			return err
+		}
+		if dst.OverflowFloat(r) {
+			return v.convertError(target, strconv.ErrRange)
+		}
+		dst.SetFloat(r)
+	case reflect.Bool:
+		r, err := v.toBool(target)
+		if err != nil {
+			return err
+		}
+		dst.SetBool(r)
+	case reflect.String:
+		dst.SetString(v.String())
+	case reflect.Slice:
+		if dst.Type().Elem().Kind() != reflect.Uint8 {
+			return v.convertError(target, ErrTypeMismatch)
+		}
+		var b []byte
+		if v.isText() {
+			b = append([]byte{}, v.Inner...)
+		} else {
+			b = []byte(v.String())
+		}
+		dst.SetBytes(b)
+	default:
+		return v.convertError(target, ErrTypeMismatch)
+	}
+	return nil
+}
@@ -5,11 +5,15 @@ package mysql
 */
 import "C"
 import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
 	"reflect"
+	"time"
 	"unsafe"
 )
 
-var (
+const (
 	c_TRUE  = C.my_bool(1)
 	c_FALSE = C.my_bool(0)
 )
@@ -22,16 +26,32 @@ type Stmt struct {
 	bindPtr  *C.MYSQL_BIND
 	binds    []C.MYSQL_BIND
 	bind_pos int
+	refs     []bindRef
+}
+
+// Parameter bound by pointer, the value is copied again before every execution.
+type bindRef struct {
+	pos       int
+	paramType C.enum_enum_field_types
+	ptr       unsafe.Pointer
+	length    int
 }
 
 // Prepare a statement.
-func (conn *Connection) Prepare(sql string) (*Stmt, error) {
+func (conn *Connection) Prepare(sql string) (stmt *Stmt, err error) {
+	conn.do(func() {
+		stmt, err = conn.prepare(sql)
+	})
+	return
+}
+
+func (conn *Connection) prepare(sql string) (*Stmt, error) {
 	stmt := &Stmt{}
 	stmt.conn = conn
 	stmt.sql = sql
 
 	if C.my_prepare(&stmt.s, &stmt.bindPtr, &conn.c, (*C.char)(stringPointer(sql)), C.ulong(len(sql))) != 0 {
-		return nil, conn.lastError(sql)
+		return nil, conn.lastError("prepare", sql)
 	}
 
 	if stmt.bindPtr != nil {
@@ -46,6 +66,7 @@ func (conn *Connection) Prepare(sql string) (*Stmt, error) {
 // Clean bind parameters.
 func (stmt *Stmt) CleanBind() {
 	stmt.bind_pos = 0
+	stmt.refs = stmt.refs[:0]
 }
 
 // Number of input arguments.
@@ -54,141 +75,238 @@ func (stmt *Stmt) NumInput() int {
 }
 
 // Bind a int parameter.
-func (stmt *Stmt) BindInt(value int32) {
-	stmt.binds[stmt.bind_pos] = C.MYSQL_BIND{
-		buffer_type: C.MYSQL_TYPE_LONG,
-		buffer:      unsafe.Pointer(&value),
-		is_null:     &c_FALSE,
-	}
-	stmt.bind_pos++
+func (stmt *Stmt) BindInt(value int32) error {
+	return stmt.bindParam(C.MYSQL_TYPE_LONG, unsafe.Pointer(&value), 4, false, false)
 }
 
 // Bind a tinyint parameter.
-func (stmt *Stmt) BindTinyInt(value int8) {
-	stmt.binds[stmt.bind_pos] = C.MYSQL_BIND{
-		buffer_type: C.MYSQL_TYPE_TINY,
-		buffer:      unsafe.Pointer(&value),
-		is_null:     &c_FALSE,
-	}
-	stmt.bind_pos++
+func (stmt *Stmt) BindTinyInt(value int8) error {
+	return stmt.bindParam(C.MYSQL_TYPE_TINY, unsafe.Pointer(&value), 1, false, false)
 }
 
 // Bind a smallint parameter.
-func (stmt *Stmt) BindSmallInt(value int16) {
-	stmt.binds[stmt.bind_pos] = C.MYSQL_BIND{
-		buffer_type: C.MYSQL_TYPE_SHORT,
-		buffer:      unsafe.Pointer(&value),
-		is_null:     &c_FALSE,
-	}
-	stmt.bind_pos++
+func (stmt *Stmt) BindSmallInt(value int16) error {
+	return stmt.bindParam(C.MYSQL_TYPE_SHORT, unsafe.Pointer(&value), 2, false, false)
 }
 
 // Bind a bigint parameter.
-func (stmt *Stmt) BindBigInt(value int64) {
-	stmt.binds[stmt.bind_pos] = C.MYSQL_BIND{
-		buffer_type: C.MYSQL_TYPE_LONGLONG,
-		buffer:      unsafe.Pointer(&value),
-		is_null:     &c_FALSE,
+func (stmt *Stmt) BindBigInt(value int64) error {
+	return stmt.bindParam(C.MYSQL_TYPE_LONGLONG, unsafe.Pointer(&value), 8, false, false)
+}
+
+// Bind a bigint unsigned parameter.
+func (stmt *Stmt) BindUint64(value uint64) error {
+	return stmt.bindParam(C.MYSQL_TYPE_LONGLONG, unsafe.Pointer(&value), 8, false, true)
+}
+
+// Bind a bool parameter as tinyint 1 or 0.
+func (stmt *Stmt) BindBool(value bool) error {
+	var v int8
+	if value {
+		v = 1
 	}
-	stmt.bind_pos++
+	return stmt.BindTinyInt(v)
 }
 
 // Bind a float parameter.
-func (stmt *Stmt) BindFloat(value float32) {
-	stmt.binds[stmt.bind_pos] = C.MYSQL_BIND{
-		buffer_type: C.MYSQL_TYPE_FLOAT,
-		buffer:      unsafe.Pointer(&value),
-		is_null:     &c_FALSE,
-	}
-	stmt.bind_pos++
+func (stmt *Stmt) BindFloat(value float32) error {
+	return stmt.bindParam(C.MYSQL_TYPE_FLOAT, unsafe.Pointer(&value), 4, false, false)
 }
 
 // Bind a double parameter.
-func (stmt *Stmt) BindDouble(value float64) {
-	stmt.binds[stmt.bind_pos] = C.MYSQL_BIND{
-		buffer_type: C.MYSQL_TYPE_DOUBLE,
-		buffer:      unsafe.Pointer(&value),
-		is_null:     &c_FALSE,
-	}
-	stmt.bind_pos++
+func (stmt *Stmt) BindDouble(value float64) error {
+	return stmt.bindParam(C.MYSQL_TYPE_DOUBLE, unsafe.Pointer(&value), 8, false, false)
 }
 
 // Bind a text parameter.
-func (stmt *Stmt) BindText(value string) {
-	stmt.binds[stmt.bind_pos] = C.MYSQL_BIND{
-		buffer_type:   C.MYSQL_TYPE_VAR_STRING,
-		buffer:        stringPointer(value),
-		buffer_length: (C.ulong)(len(value)),
-		is_null:       &c_FALSE,
+func (stmt *Stmt) BindText(value string) error {
+	return stmt.bindParam(C.MYSQL_TYPE_VAR_STRING, stringPointer(value), len(value), false, false)
+}
+
+// Bind a blob parameter. nil value is bound as NULL.
+func (stmt *Stmt) BindBlob(value []byte) error {
+	return stmt.bindParam(C.MYSQL_TYPE_BLOB, bytePointer(value), len(value), value == nil, false)
+}
+
+// Bind a datetime parameter. The wall clock of value in its own location is used,
+// call value.In(loc) before bind to convert it. Zero time is bound as '0000-00-00 00:00:00'.
+func (stmt *Stmt) BindTime(value time.Time) error {
+	var mt C.MYSQL_TIME
+	if !value.IsZero() {
+		mt.year = C.uint(value.Year())
+		mt.month = C.uint(value.Month())
+		mt.day = C.uint(value.Day())
+		mt.hour = C.uint(value.Hour())
+		mt.minute = C.uint(value.Minute())
+		mt.second = C.uint(value.Second())
+		mt.second_part = C.ulong(value.Nanosecond() / 1000)
 	}
-	stmt.bind_pos++
+	mt.time_type = C.MYSQL_TIMESTAMP_DATETIME
+	return stmt.bindParam(C.MYSQL_TYPE_DATETIME, unsafe.Pointer(&mt), C.sizeof_MYSQL_TIME, false, false)
 }
 
-// Bind a blob parameter.
-func (stmt *Stmt) BindBlob(value []byte) {
-	stmt.binds[stmt.bind_pos] = C.MYSQL_BIND{
-		buffer_type:   C.MYSQL_TYPE_BLOB,
-		buffer:        bytePointer(value),
-		buffer_length: (C.ulong)(len(value)),
-		is_null:       cbool(value == nil),
+// Bind a time parameter. The precision is microsecond.
+func (stmt *Stmt) BindDuration(value time.Duration) error {
+	var mt C.MYSQL_TIME
+	if value < 0 {
+		mt.neg = c_TRUE
+		value = -value
 	}
-	stmt.bind_pos++
+	mt.hour = C.uint(value / time.Hour)
+	mt.minute = C.uint(value % time.Hour / time.Minute)
+	mt.second = C.uint(value % time.Minute / time.Second)
+	mt.second_part = C.ulong(value % time.Second / time.Microsecond)
+	mt.time_type = C.MYSQL_TIMESTAMP_TIME
+	return stmt.bindParam(C.MYSQL_TYPE_TIME, unsafe.Pointer(&mt), C.sizeof_MYSQL_TIME, false, false)
 }
 
-func (stmt *Stmt) bind(paramType TypeCode, valuePtr unsafe.Pointer) {
-	stmt.binds[stmt.bind_pos] = C.MYSQL_BIND{
-		buffer_type: uint32(paramType),
-		buffer:      valuePtr,
-		is_null:     cbool(valuePtr == nil),
+// Bind a NULL parameter.
+func (stmt *Stmt) BindNull() error {
+	return stmt.bindParam(C.MYSQL_TYPE_NULL, nil, 0, true, false)
+}
+
+// Copy the parameter data into C memory owned by the statement.
+// The cgo rules not allow C memory keep Go pointers, so the MYSQL_BIND never point to Go memory.
+func (stmt *Stmt) bindParam(paramType C.enum_enum_field_types, data unsafe.Pointer, length int, isNull, isUnsigned bool) error {
+	if stmt.bind_pos >= len(stmt.binds) {
+		return fmt.Errorf("mysql: too many parameters, the statement has %d", len(stmt.binds))
+	}
+	if C.my_stmt_bind(stmt.s, stmt.bindPtr, C.ulong(stmt.bind_pos), paramType, data, C.ulong(length), cbool(isNull), cbool(isUnsigned)) != 0 {
+		return errors.New("mysql: out of memory")
 	}
 	stmt.bind_pos++
+	return nil
 }
 
-// Bind parameter.
-func (stmt *Stmt) Bind(value interface{}) {
+// Bind parameter. nil is bound as NULL. *int8, *int16, *int32, *int64, *float32 and *float64
+// are bound by reference, their values are read when the statement executed. Other pointers are
+// dereferenced at bind time.
+// Values implement driver.Valuer are bound by the result of Value(), values implement
+// fmt.Stringer are bound as text. Other types return an error.
+func (stmt *Stmt) Bind(value interface{}) error {
 	switch v := value.(type) {
+	case nil:
+		return stmt.BindNull()
 	case int:
-		stmt.BindBigInt(int64(v))
+		return stmt.BindBigInt(int64(v))
 	case int8:
-		stmt.BindTinyInt(v)
+		return stmt.BindTinyInt(v)
 	case int16:
-		stmt.BindSmallInt(v)
+		return stmt.BindSmallInt(v)
 	case int32:
-		stmt.BindInt(v)
+		return stmt.BindInt(v)
 	case int64:
-		stmt.BindBigInt(v)
+		return stmt.BindBigInt(v)
+	case uint:
+		return stmt.BindUint64(uint64(v))
+	case uint8:
+		return stmt.BindUint64(uint64(v))
+	case uint16:
+		return stmt.BindUint64(uint64(v))
+	case uint32:
+		return stmt.BindUint64(uint64(v))
+	case uint64:
+		return stmt.BindUint64(v)
+	case bool:
+		return stmt.BindBool(v)
 	case float32:
-		stmt.BindFloat(v)
+		return stmt.BindFloat(v)
 	case float64:
-		stmt.BindDouble(v)
+		return stmt.BindDouble(v)
 	case string:
-		stmt.BindText(v)
+		return stmt.BindText(v)
 	case []byte:
-		stmt.BindBlob(v)
+		return stmt.BindBlob(v)
+	case time.Time:
+		return stmt.BindTime(v)
+	case time.Duration:
+		return stmt.BindDuration(v)
 	case *int8:
-		stmt.bind(C.MYSQL_TYPE_TINY, unsafe.Pointer(v))
+		return stmt.bindPointer(C.MYSQL_TYPE_TINY, unsafe.Pointer(v), 1)
 	case *int16:
-		stmt.bind(C.MYSQL_TYPE_SHORT, unsafe.Pointer(v))
+		return stmt.bindPointer(C.MYSQL_TYPE_SHORT, unsafe.Pointer(v), 2)
 	case *int32:
-		stmt.bind(C.MYSQL_TYPE_LONG, unsafe.Pointer(v))
+		return stmt.bindPointer(C.MYSQL_TYPE_LONG, unsafe.Pointer(v), 4)
 	case *int64:
-		stmt.bind(C.MYSQL_TYPE_LONGLONG, unsafe.Pointer(v))
+		return stmt.bindPointer(C.MYSQL_TYPE_LONGLONG, unsafe.Pointer(v), 8)
 	case *float32:
-		stmt.bind(C.MYSQL_TYPE_FLOAT, unsafe.Pointer(v))
+		return stmt.bindPointer(C.MYSQL_TYPE_FLOAT, unsafe.Pointer(v), 4)
 	case *float64:
-		stmt.bind(C.MYSQL_TYPE_DOUBLE, unsafe.Pointer(v))
-	default:
-		panic("unknow parameter type")
+		return stmt.bindPointer(C.MYSQL_TYPE_DOUBLE, unsafe.Pointer(v), 8)
+	case driver.Valuer:
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return stmt.BindNull()
+		}
+		dv, err := v.Value()
+		if err != nil {
+			return err
+		}
+		if _, ok := dv.(driver.Valuer); ok {
+			return fmt.Errorf("mysql: driver.Valuer %T returns another driver.Valuer", v)
+		}
+		return stmt.Bind(dv)
+	case fmt.Stringer:
+		return stmt.BindText(v.String())
+	}
+
+	// Other pointers and named types, e.g. *string or "type Status int".
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return stmt.BindNull()
+		}
+		return stmt.Bind(rv.Elem().Interface())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return stmt.BindBigInt(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return stmt.BindUint64(rv.Uint())
+	case reflect.Bool:
+		return stmt.BindBool(rv.Bool())
+	case reflect.Float32, reflect.Float64:
+		return stmt.BindDouble(rv.Float())
+	case reflect.String:
+		return stmt.BindText(rv.String())
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return stmt.BindBlob(rv.Bytes())
+		}
+	}
+	return fmt.Errorf("mysql: unsupported parameter type %T", value)
+}
+
+func (stmt *Stmt) bindPointer(paramType C.enum_enum_field_types, valuePtr unsafe.Pointer, length int) error {
+	if valuePtr == nil {
+		return stmt.bindParam(paramType, nil, 0, true, false)
+	}
+	pos := stmt.bind_pos
+	if err := stmt.bindParam(paramType, valuePtr, length, false, false); err != nil {
+		return err
 	}
+	stmt.refs = append(stmt.refs, bindRef{pos, paramType, valuePtr, length})
+	return nil
+}
+
+// Copy the current values of parameters bound by pointer.
+func (stmt *Stmt) rebindRefs() error {
+	for _, ref := range stmt.refs {
+		if C.my_stmt_bind(stmt.s, stmt.bindPtr, C.ulong(ref.pos), ref.paramType, ref.ptr, C.ulong(ref.length), c_FALSE, c_FALSE) != 0 {
+			return errors.New("mysql: out of memory")
+		}
+	}
+	return nil
 }
 
 func (stmt *Stmt) execute(res *stmtResult, mode C.MY_MODE) error {
 	if stmt.conn.IsClosed() {
-		return &SqlError{Num: 2006, Message: "Connection is closed"}
+		return stmt.closedError(modeOp(mode))
 	}
 
+	if err := stmt.rebindRefs(); err != nil {
+		return err
+	}
 	if C.my_stmt_execute(stmt.s, stmt.bindPtr, &res.c, mode) != 0 {
-		return stmt.lastError()
+		return stmt.lastError(modeOp(mode))
 	}
 	return nil
 }
@@ -203,7 +321,53 @@ func (stmt *Stmt) query(res *stmtQueryResult, mode C.MY_MODE) error {
 }
 
 // Execute statement as none-query.
-func (stmt *Stmt) Execute() (Result, error) {
+func (stmt *Stmt) Execute() (res Result, err error) {
+	stmt.conn.do(func() {
+		res, err = stmt.executeResult()
+		if err == nil {
+			err = stmt.conn.checkWarnings(res)
+		}
+	})
+	return
+}
+
+// Execute statement and fill result into a DataTable.
+func (stmt *Stmt) QueryTable() (res DataTable, err error) {
+	stmt.conn.do(func() {
+		res, err = stmt.queryTable()
+		if err == nil {
+			err = stmt.conn.checkWarnings(res)
+		}
+	})
+	return
+}
+
+// Execute statement and return a result reader. NOTE: Please remember close the reader.
+func (stmt *Stmt) QueryReader() (res DataReader, err error) {
+	stmt.conn.do(func() {
+		res, err = stmt.queryReader()
+	})
+	return
+}
+
+// Execute statement and iterate all the results, used to CALL a stored procedure.
+// NOTE: Please remember close the result.
+func (stmt *Stmt) QueryMulti() (res MultiResult, err error) {
+	stmt.conn.do(func() {
+		res, err = stmt.queryMulti()
+	})
+	return
+}
+
+// Close and dispose the statement.
+func (stmt *Stmt) Close() (err error) {
+	stmt.conn.do(func() {
+		err = stmt.close()
+	})
+	return
+}
+
+func (stmt *Stmt) executeResult() (Result, error) {
 	res := &stmtResult{}
 	res.s = stmt.s
 
@@ -214,8 +378,20 @@ func (stmt *Stmt) Execute() (Result, error) {
 	return res, nil
 }
 
-// Execute statement and fill result into a DataTable.
-func (stmt *Stmt) QueryTable() (DataTable, error) {
+func (stmt *Stmt) queryMulti() (MultiResult, error) {
+	if stmt.conn.IsClosed() {
+		return nil, stmt.closedError("query")
+	}
+	if err := stmt.rebindRefs(); err != nil {
+		return nil, err
+	}
+	if C.my_stmt_execute_multi(stmt.s, stmt.bindPtr) != 0 {
+		return nil, stmt.lastError("query")
+	}
+	return &stmtMultiResult{stmt: stmt}, nil
+}
+
+func (stmt *Stmt) queryTable() (DataTable, error) {
 	res := &stmtDataTable{}
 	res.s = stmt.s
 
@@ -231,8 +407,7 @@ func (stmt *Stmt) QueryTable() (DataTable, error) {
 	return res, nil
 }
 
-// Execute statement and return a result reader. NOTE: Please remember close the reader.
-func (stmt *Stmt) QueryReader() (DataReader, error) {
+func (stmt *Stmt) queryReader() (DataReader, error) {
 	res := &stmtDataReader{}
 	res.s = stmt.s
 
@@ -243,22 +418,22 @@ func (stmt *Stmt) QueryReader() (DataReader, error) {
 	return res, nil
 }
 
-// Close and dispose the statement.
-func (stmt *Stmt) Close() error {
+func (stmt *Stmt) close() error {
 	if stmt.s == nil {
 		return nil
 	}
 	if C.my_stmt_close(stmt.s, stmt.bindPtr) != 0 {
-		return stmt.lastError()
+		return stmt.lastError("close")
 	}
 	stmt.s = nil
 	stmt.binds = nil
+	stmt.refs = nil
 	return nil
 }
 
-func cbool(gobool bool) *C.my_bool {
+func cbool(gobool bool) C.my_bool {
 	if gobool {
-		return &c_TRUE
+		return c_TRUE
 	}
-	return &c_FALSE
+	return c_FALSE
 }
@@ -18,6 +18,10 @@ func (res *stmtResult) InsertId() int64 {
 	return int64(res.c.insert_id)
 }
 
+func (res *stmtResult) WarningCount() int {
+	return int(res.c.warning_count)
+}
+
 func (res *stmtResult) close() {
 	C.my_stmt_close_result(res.s, &res.c)
 }
@@ -35,7 +39,7 @@ func (res *stmtQueryResult) fillFields() {
 func (res *stmtQueryResult) fetchNext() (row []Value, err error) {
 	crow := C.my_stmt_fetch_next(res.s, &res.c)
 	if crow.has_error != 0 {
-		return nil, res.stmt.lastError()
+		return nil, res.stmt.lastError("fetch")
 	}
 
 	return fetchNext(res.stmt.s.meta, crow, true)
@@ -66,7 +70,7 @@ func (res *stmtDataTable) fillRows(stmt *Stmt) (err error) {
 	}
 
 	if rowCount < 0 {
-		return stmt.lastError()
+		return stmt.lastError("fetch")
 	}
 
 	rows := make([][]Value, rowCount)
@@ -85,14 +89,180 @@ func (res *stmtDataTable) Rows() [][]Value {
 	return res.rows
 }
 
+func (res *stmtDataTable) ScanAll(dst interface{}) error {
+	return scanAll(res.fields, res.rows, dst)
+}
+
 type stmtDataReader struct {
 	stmtQueryResult
+	row []Value // the last fetched row.
+}
+
+func (res *stmtDataReader) FetchNext() (row []Value, err error) {
+	res.stmt.conn.do(func() {
+		row, err = res.fetchNext()
+	})
+	res.row = row
+	return
+}
+
+func (res *stmtDataReader) Scan(dest ...interface{}) error {
+	return ScanRow(res.row, dest...)
 }
 
-func (res *stmtDataReader) FetchNext() ([]Value, error) {
-	return res.fetchNext()
+func (res *stmtDataReader) ScanStruct(dst interface{}) error {
+	return scanRowStruct(res.fields, res.row, dst)
 }
 
 func (res *stmtDataReader) Close() {
-	res.close()
+	res.stmt.conn.do(res.close)
+}
+
+type stmtMultiResult struct {
+	stmt    *Stmt
+	started bool
+	done    bool
+	err     error
+	reader  *stmtMultiReader // the reader of current result, closed before moving to the next result.
+}
+
+func (mr *stmtMultiResult) NextResult() (res DataTable, ok bool) {
+	mr.stmt.conn.do(func() {
+		res, ok = mr.nextResult(true)
+	})
+	return
+}
+
+func (mr *stmtMultiResult) NextReader() (res DataReader, ok bool) {
+	mr.stmt.conn.do(func() {
+		res, ok = mr.nextReader()
+	})
+	return
+}
+
+func (mr *stmtMultiResult) HasNext() (ok bool) {
+	mr.stmt.conn.do(func() {
+		ok = !mr.done && (!mr.started || C.my_more_results(mr.stmt.s.mysql) != 0)
+	})
+	return
+}
+
+func (mr *stmtMultiResult) Err() error {
+	return mr.err
+}
+
+func (mr *stmtMultiResult) Close() {
+	mr.stmt.conn.do(func() {
+		for !mr.done {
+			mr.nextResult(false)
+		}
+		C.my_stmt_close_result(mr.stmt.s, nil)
+	})
+}
+
+// Move to the next result, returns false when no more results or an error occurred.
+func (mr *stmtMultiResult) next() bool {
+	if mr.done {
+		return false
+	}
+
+	if mr.reader != nil {
+		mr.reader.close()
+		mr.reader = nil
+	}
+
+	stmt := mr.stmt
+	if mr.started {
+		switch C.my_stmt_next_result(stmt.s) {
+		case 0:
+		case -1:
+			mr.done = true
+			return false
+		default:
+			mr.err = stmt.lastError("next result")
+			mr.done = true
+			return false
+		}
+	}
+	mr.started = true
+	return true
+}
+
+func (mr *stmtMultiResult) nextResult(fill bool) (DataTable, bool) {
+	if !mr.next() {
+		return nil, false
+	}
+
+	stmt := mr.stmt
+	res := &stmtDataTable{}
+	res.s = stmt.s
+	if C.my_stmt_store_result(stmt.s, &res.c, C.MY_MODE_TABLE) != 0 {
+		mr.err = stmt.lastError("next result")
+		mr.done = true
+		return nil, false
+	}
+
+	if !fill {
+		return nil, true
+	}
+
+	res.stmt = stmt
+	res.fillFields()
+	if len(res.fields) != 0 {
+		if err := res.fillRows(stmt); err != nil {
+			mr.err = err
+			mr.done = true
+			return nil, false
+		}
+	}
+	return res, true
+}
+
+func (mr *stmtMultiResult) nextReader() (DataReader, bool) {
+	if !mr.next() {
+		return nil, false
+	}
+
+	stmt := mr.stmt
+	res := &stmtMultiReader{}
+	res.s = stmt.s
+	if C.my_stmt_store_result(stmt.s, &res.c, C.MY_MODE_READER) != 0 {
+		mr.err = stmt.lastError("next result")
+		mr.done = true
+		return nil, false
+	}
+
+	res.stmt = stmt
+	res.fillFields()
+	mr.reader = res
+	return res, true
+}
+
+// Reader of a result in stmtMultiResult. Closing it keeps the following results.
+type stmtMultiReader struct {
+	stmtDataReader
+	closed bool
+}
+
+// Returns nil after the reader closed, the statement handle may be reading the next result.
+func (res *stmtMultiReader) FetchNext() (row []Value, err error) {
+	res.stmt.conn.do(func() {
+		if !res.closed {
+			row, err = res.fetchNext()
+		}
+	})
+	res.row = row
+	return
+}
+
+func (res *stmtMultiReader) Close() {
+	res.stmt.conn.do(res.close)
+}
+
+func (res *stmtMultiReader) close() {
+	if !res.closed {
+		// Fetch the remaining rows, so the next result can be read.
+		C.my_stmt_close_result(res.s, &res.c)
+		res.closed = true
+	}
 }
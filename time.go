@@ -0,0 +1,194 @@
+package mysql
+
+/*
+#include "cgo.h"
+*/
+import "C"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+// Convert DATE, DATETIME or TIMESTAMP value to time.Time in loc. Zero dates (e.g. "0000-00-00") return zero time.Time.
+// loc is used to interpret the value, nil means UTC.
+func (v *Value) Time(loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	if v.IsNull() {
+		return time.Time{}, v.convertError("time.Time", ErrNullValue)
+	}
+
+	switch v.Type {
+	case MYSQL_TYPE_DATE, MYSQL_TYPE_NEWDATE, MYSQL_TYPE_DATETIME, MYSQL_TYPE_TIMESTAMP:
+	default:
+		return time.Time{}, v.convertError("time.Time", ErrTypeMismatch)
+	}
+
+	if mt, ok := v.mysqlTime(); ok {
+		if mt.year == 0 && mt.month == 0 && mt.day == 0 {
+			return time.Time{}, nil
+		}
+		return time.Date(
+			int(mt.year), time.Month(mt.month), int(mt.day),
+			int(mt.hour), int(mt.minute), int(mt.second), int(mt.second_part)*1000,
+			loc,
+		), nil
+	}
+
+	t, err := parseDateTime(byteString(v.Inner), loc)
+	if err != nil {
+		return time.Time{}, v.convertError("time.Time", strconv.ErrSyntax)
+	}
+	return t, nil
+}
+
+// Convert TIME value to time.Duration. TIME value range is '-838:59:59.000000' to '838:59:59.000000'.
+func (v *Value) Duration() (time.Duration, error) {
+	if v.IsNull() {
+		return 0, v.convertError("time.Duration", ErrNullValue)
+	}
+
+	if v.Type != MYSQL_TYPE_TIME {
+		return 0, v.convertError("time.Duration", ErrTypeMismatch)
+	}
+
+	if mt, ok := v.mysqlTime(); ok {
+		d := time.Duration(mt.day)*24*time.Hour +
+			time.Duration(mt.hour)*time.Hour +
+			time.Duration(mt.minute)*time.Minute +
+			time.Duration(mt.second)*time.Second +
+			time.Duration(mt.second_part)*time.Microsecond
+		if mt.neg != 0 {
+			d = -d
+		}
+		return d, nil
+	}
+
+	d, err := parseTime(byteString(v.Inner))
+	if err != nil {
+		return 0, v.convertError("time.Duration", strconv.ErrSyntax)
+	}
+	return d, nil
+}
+
+// Get the MYSQL_TIME struct of prepared statement value.
+func (v *Value) mysqlTime() (mt C.MYSQL_TIME, ok bool) {
+	if !v.isStmtValue || len(v.Inner) < C.sizeof_MYSQL_TIME {
+		return mt, false
+	}
+	switch v.Type {
+	case MYSQL_TYPE_DATE, MYSQL_TYPE_DATETIME, MYSQL_TYPE_TIMESTAMP, MYSQL_TYPE_TIME:
+	default:
+		return mt, false
+	}
+	// Copy to avoid unaligned access.
+	copy((*[C.sizeof_MYSQL_TIME]byte)(unsafe.Pointer(&mt))[:], v.Inner)
+	return mt, true
+}
+
+// Format the MYSQL_TIME struct of prepared statement value like the text protocol.
+func (v *Value) formatTime() string {
+	mt, ok := v.mysqlTime()
+	if !ok {
+		return string(v.Inner)
+	}
+
+	var s string
+	switch v.Type {
+	case MYSQL_TYPE_DATE:
+		return fmt.Sprintf("%04d-%02d-%02d", mt.year, mt.month, mt.day)
+	case MYSQL_TYPE_TIME:
+		sign := ""
+		if mt.neg != 0 {
+			sign = "-"
+		}
+		s = fmt.Sprintf("%s%02d:%02d:%02d", sign, mt.day*24+mt.hour, mt.minute, mt.second)
+	default:
+		s = fmt.Sprintf("%04d-%02d-%02d %02d:%02d:%02d", mt.year, mt.month, mt.day, mt.hour, mt.minute, mt.second)
+	}
+
+	if mt.second_part != 0 {
+		s += fmt.Sprintf(".%06d", mt.second_part)
+	}
+	return s
+}
+
+// Parse text protocol DATE, DATETIME and TIMESTAMP value.
+func parseDateTime(s string, loc *time.Location) (time.Time, error) {
+	if isZeroDateTime(s) {
+		return time.Time{}, nil
+	}
+
+	layout := "2006-01-02"
+	if len(s) > len(layout) {
+		// Fractional seconds are accepted after the seconds field even if the layout not has it.
+		layout = "2006-01-02 15:04:05"
+	}
+
+	t, err := time.ParseInLocation(layout, s, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("mysql: invalid datetime value %q", s)
+	}
+	return t, nil
+}
+
+func isZeroDateTime(s string) bool {
+	return len(s) >= 10 && strings.Trim(s, "0-:. ") == ""
+}
+
+// Parse text protocol TIME value, format is [-]HHH:MM:SS[.ffffff].
+func parseTime(s string) (time.Duration, error) {
+	invalid := fmt.Errorf("mysql: invalid time value %q", s)
+
+	neg := false
+	str := s
+	if strings.HasPrefix(str, "-") {
+		neg = true
+		str = str[1:]
+	}
+
+	var frac string
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		str, frac = str[:i], str[i+1:]
+	}
+
+	parts := strings.Split(str, ":")
+	if len(parts) != 3 {
+		return 0, invalid
+	}
+
+	var hms [3]int64
+	for i, p := range parts {
+		n, err := strconv.ParseUint(p, 10, 32)
+		if err != nil {
+			return 0, invalid
+		}
+		hms[i] = int64(n)
+	}
+
+	d := time.Duration(hms[0])*time.Hour + time.Duration(hms[1])*time.Minute + time.Duration(hms[2])*time.Second
+
+	if frac != "" {
+		if len(frac) > 9 {
+			frac = frac[:9]
+		}
+		n, err := strconv.ParseUint(frac, 10, 32)
+		if err != nil {
+			return 0, invalid
+		}
+		for i := len(frac); i < 9; i++ {
+			n *= 10
+		}
+		d += time.Duration(n)
+	}
+
+	if neg {
+		d = -d
+	}
+	return d, nil
+}
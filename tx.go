@@ -0,0 +1,222 @@
+package mysql
+
+import (
+	"errors"
+	"strings"
+)
+
+// Returned by the methods of Tx after the transaction committed or rolled back.
+var ErrTxDone = errors.New("mysql: transaction has already been committed or rolled back")
+
+// Returned by Begin() when the connection has a transaction not committed or rolled back.
+var ErrTxActive = errors.New("mysql: the connection already has an active transaction")
+
+// Transaction isolation level.
+type IsolationLevel int
+
+const (
+	IsolationDefault         IsolationLevel = iota // Use the session isolation level.
+	IsolationReadUncommitted                       // READ UNCOMMITTED
+	IsolationReadCommitted                         // READ COMMITTED
+	IsolationRepeatableRead                        // REPEATABLE READ
+	IsolationSerializable                          // SERIALIZABLE
+)
+
+// Get the SQL name of isolation level.
+func (level IsolationLevel) String() string {
+	switch level {
+	case IsolationReadUncommitted:
+		return "READ UNCOMMITTED"
+	case IsolationReadCommitted:
+		return "READ COMMITTED"
+	case IsolationRepeatableRead:
+		return "REPEATABLE READ"
+	case IsolationSerializable:
+		return "SERIALIZABLE"
+	}
+	return "DEFAULT"
+}
+
+// Transaction options.
+type TxOptions struct {
+	Isolation          IsolationLevel // Isolation level of the transaction.
+	ReadOnly           bool           // START TRANSACTION READ ONLY.
+	ConsistentSnapshot bool           // START TRANSACTION WITH CONSISTENT SNAPSHOT.
+}
+
+// Transaction. Must be committed or rolled back, the connection can't begin another transaction before that.
+type Tx struct {
+	conn *Connection
+	done bool
+}
+
+// Begin a transaction.
+func (conn *Connection) Begin(opts TxOptions) (tx *Tx, err error) {
+	conn.do(func() {
+		tx, err = conn.begin(opts)
+	})
+	return
+}
+
+func (conn *Connection) begin(opts TxOptions) (*Tx, error) {
+	// Another START TRANSACTION commits the active transaction implicitly.
+	if conn.tx != nil {
+		return nil, ErrTxActive
+	}
+
+	if opts.Isolation != IsolationDefault {
+		if opts.Isolation < IsolationDefault || opts.Isolation > IsolationSerializable {
+			return nil, errors.New("mysql: unknown isolation level")
+		}
+		// Only affect the next transaction.
+		if _, err := conn.executeResult("SET TRANSACTION ISOLATION LEVEL " + opts.Isolation.String()); err != nil {
+			return nil, err
+		}
+	}
+
+	var characteristics []string
+	if opts.ConsistentSnapshot {
+		characteristics = append(characteristics, "WITH CONSISTENT SNAPSHOT")
+	}
+	if opts.ReadOnly {
+		characteristics = append(characteristics, "READ ONLY")
+	}
+
+	sql := "START TRANSACTION"
+	if len(characteristics) > 0 {
+		sql += " " + strings.Join(characteristics, ", ")
+	}
+	if _, err := conn.executeResult(sql); err != nil {
+		if opts.Isolation != IsolationDefault {
+			// The isolation level is still pending, restore it, otherwise the next transaction uses it.
+			if _, err := conn.executeResult("SET @@transaction_isolation = @@SESSION.transaction_isolation"); err != nil {
+				// MySQL before 5.7.20
+				conn.executeResult("SET @@tx_isolation = @@SESSION.tx_isolation")
+			}
+		}
+		return nil, err
+	}
+	conn.tx = &Tx{conn: conn}
+	return conn.tx, nil
+}
+
+// Begin a transaction and run f in it. The transaction is rolled back if f returns an error or panics,
+// otherwise it is committed. f can commit or roll back the transaction by itself.
+func (conn *Connection) RunInTx(opts TxOptions, f func(*Tx) error) (err error) {
+	tx, err := conn.Begin(opts)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err = f(tx); err != nil {
+		if !tx.Done() {
+			tx.Rollback()
+		}
+		return err
+	}
+
+	if tx.Done() {
+		return nil
+	}
+	return tx.Commit()
+}
+
+// Get the connection of transaction.
+func (tx *Tx) Conn() *Connection {
+	return tx.conn
+}
+
+// Check the transaction is committed or rolled back.
+func (tx *Tx) Done() bool {
+	return tx.done
+}
+
+// Commit the transaction.
+func (tx *Tx) Commit() error {
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+	return tx.conn.Commit()
+}
+
+// Roll back the transaction.
+func (tx *Tx) Rollback() error {
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+	return tx.conn.Rollback()
+}
+
+// Create a savepoint with name.
+func (tx *Tx) Savepoint(name string) error {
+	return tx.exec("SAVEPOINT " + quoteIdentifier(name))
+}
+
+// Remove the savepoint with name.
+func (tx *Tx) ReleaseSavepoint(name string) error {
+	return tx.exec("RELEASE SAVEPOINT " + quoteIdentifier(name))
+}
+
+// Roll back the transaction to the savepoint with name, the savepoint is kept.
+func (tx *Tx) RollbackTo(name string) error {
+	return tx.exec("ROLLBACK TO SAVEPOINT " + quoteIdentifier(name))
+}
+
+// Execute a non-query SQL in the transaction.
+func (tx *Tx) Execute(sql string) (Result, error) {
+	if tx.done {
+		return nil, ErrTxDone
+	}
+	return tx.conn.Execute(sql)
+}
+
+// Execute a query in the transaction and fill result into a DataTable.
+func (tx *Tx) QueryTable(sql string) (DataTable, error) {
+	if tx.done {
+		return nil, ErrTxDone
+	}
+	return tx.conn.QueryTable(sql)
+}
+
+// Execute a query in the transaction and return the result reader. NOTE: Please remember close the reader.
+func (tx *Tx) QueryReader(sql string) (DataReader, error) {
+	if tx.done {
+		return nil, ErrTxDone
+	}
+	return tx.conn.QueryReader(sql)
+}
+
+// Prepare a statement in the transaction.
+func (tx *Tx) Prepare(sql string) (*Stmt, error) {
+	if tx.done {
+		return nil, ErrTxDone
+	}
+	return tx.conn.Prepare(sql)
+}
+
+// Mark the active transaction done, so the connection can begin another one.
+func (conn *Connection) endTx() {
+	if conn.tx != nil {
+		conn.tx.done = true
+		conn.tx = nil
+	}
+}
+
+func (tx *Tx) exec(sql string) error {
+	_, err := tx.Execute(sql)
+	return err
+}
+
+// Quote identifier with backticks.
+func quoteIdentifier(name string) string {
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
@@ -5,7 +5,12 @@ package mysql
 */
 import "C"
 import (
+	"database/sql"
+	"fmt"
+	"math"
 	"strconv"
+	"time"
+	"unsafe"
 )
 
 var NULL = Value{}
@@ -41,6 +46,81 @@ const (
 	MYSQL_TYPE_LONG_BLOB   = TypeCode(C.MYSQL_TYPE_LONG_BLOB)
 )
 
+// Get the SQL name of type.
+func (t TypeCode) String() string {
+	switch t {
+	case MYSQL_TYPE_TINY:
+		return "TINYINT"
+	case MYSQL_TYPE_SHORT:
+		return "SMALLINT"
+	case MYSQL_TYPE_LONG:
+		return "INT"
+	case MYSQL_TYPE_INT24:
+		return "MEDIUMINT"
+	case MYSQL_TYPE_LONGLONG:
+		return "BIGINT"
+	case MYSQL_TYPE_DECIMAL, MYSQL_TYPE_NEWDECIMAL:
+		return "DECIMAL"
+	case MYSQL_TYPE_FLOAT:
+		return "FLOAT"
+	case MYSQL_TYPE_DOUBLE:
+		return "DOUBLE"
+	case MYSQL_TYPE_BIT:
+		return "BIT"
+	case MYSQL_TYPE_TIMESTAMP:
+		return "TIMESTAMP"
+	case MYSQL_TYPE_DATE, MYSQL_TYPE_NEWDATE:
+		return "DATE"
+	case MYSQL_TYPE_TIME:
+		return "TIME"
+	case MYSQL_TYPE_DATETIME:
+		return "DATETIME"
+	case MYSQL_TYPE_YEAR:
+		return "YEAR"
+	case MYSQL_TYPE_STRING:
+		return "CHAR"
+	case MYSQL_TYPE_VAR_STRING:
+		return "VARCHAR"
+	case MYSQL_TYPE_TINY_BLOB:
+		return "TINYBLOB"
+	case MYSQL_TYPE_BLOB:
+		return "BLOB"
+	case MYSQL_TYPE_MEDIUM_BLOB:
+		return "MEDIUMBLOB"
+	case MYSQL_TYPE_LONG_BLOB:
+		return "LONGBLOB"
+	case MYSQL_TYPE_SET:
+		return "SET"
+	case MYSQL_TYPE_ENUM:
+		return "ENUM"
+	case MYSQL_TYPE_GEOMETRY:
+		return "GEOMETRY"
+	case MYSQL_TYPE_NULL:
+		return "NULL"
+	}
+	return fmt.Sprintf("TYPE(%d)", int64(t))
+}
+
+type FieldFlag uint32
+
+const (
+	NOT_NULL_FLAG         = FieldFlag(C.NOT_NULL_FLAG)         // Field can't be NULL
+	PRI_KEY_FLAG          = FieldFlag(C.PRI_KEY_FLAG)          // Field is part of a primary key
+	UNIQUE_KEY_FLAG       = FieldFlag(C.UNIQUE_KEY_FLAG)       // Field is part of a unique key
+	MULTIPLE_KEY_FLAG     = FieldFlag(C.MULTIPLE_KEY_FLAG)     // Field is part of a nonunique key
+	BLOB_FLAG             = FieldFlag(C.BLOB_FLAG)             // Field is a BLOB or TEXT (deprecated)
+	UNSIGNED_FLAG         = FieldFlag(C.UNSIGNED_FLAG)         // Field has the UNSIGNED attribute
+	ZEROFILL_FLAG         = FieldFlag(C.ZEROFILL_FLAG)         // Field has the ZEROFILL attribute
+	BINARY_FLAG           = FieldFlag(C.BINARY_FLAG)           // Field has the BINARY attribute
+	ENUM_FLAG             = FieldFlag(C.ENUM_FLAG)             // Field is an ENUM
+	AUTO_INCREMENT_FLAG   = FieldFlag(C.AUTO_INCREMENT_FLAG)   // Field has the AUTO_INCREMENT attribute
+	TIMESTAMP_FLAG        = FieldFlag(C.TIMESTAMP_FLAG)        // Field is a TIMESTAMP (deprecated)
+	SET_FLAG              = FieldFlag(C.SET_FLAG)              // Field is a SET
+	NO_DEFAULT_VALUE_FLAG = FieldFlag(C.NO_DEFAULT_VALUE_FLAG) // Field has no default value
+	ON_UPDATE_NOW_FLAG    = FieldFlag(C.ON_UPDATE_NOW_FLAG)    // Field is set to NOW on UPDATE
+	NUM_FLAG              = FieldFlag(C.NUM_FLAG)              // Field is numeric
+)
+
 // Non-query result.
 type Result interface {
 	// Get how many rows affected by query.
@@ -48,6 +128,10 @@ type Result interface {
 
 	// Get the last insert id of the query.
 	InsertId() int64
+
+	// Get the number of warnings generated by the query, use Connection.Warnings() to get them.
+	// For DataReader the count is available after all rows fetched.
+	WarningCount() int
 }
 
 // Query result.
@@ -67,6 +151,11 @@ type DataTable interface {
 
 	// Get all rows.
 	Rows() [][]Value
+
+	// Scan all rows into the slice pointed by dst, the slice element can be struct or pointer to struct.
+	// Columns are mapped to struct fields by the `mysql:"column"` tag or the snake_case field name,
+	// fields of embedded structs are included. Use pointer fields to receive NULL.
+	ScanAll(dst interface{}) error
 }
 
 // Result reader.
@@ -76,19 +165,102 @@ type DataReader interface {
 	// Fetch next row.
 	FetchNext() ([]Value, error)
 
+	// Scan the row returned by the last FetchNext() into the values pointed by dest, see ScanRow().
+	Scan(dest ...interface{}) error
+
+	// Scan the row returned by the last FetchNext() into the struct pointed by dst.
+	// Columns are mapped to struct fields like DataTable.ScanAll().
+	ScanStruct(dst interface{}) error
+
 	// Close and dispose result.
 	Close()
 }
 
+// Multiple results iterator, used by multi-statements and stored procedures.
+// NOTE: Please remember close it.
+type MultiResult interface {
+	// Get next result. Each statement has a result, even if it has no result set (e.g. INSERT).
+	// Returns false when no more results or an error occurred, use Err() to check.
+	NextResult() (DataTable, bool)
+
+	// Get next result as a reader, the rows are fetched from server one by one instead of filled into memory.
+	// The previous reader is closed when moving to the next result. Returns false like NextResult().
+	NextReader() (DataReader, bool)
+
+	// Check there are more results after the current one. For a reader, it's known after all rows fetched.
+	HasNext() bool
+
+	// Get the error which stopped the iteration.
+	Err() error
+
+	// Discard the remaining results.
+	Close()
+}
+
 // Field described a column returned by mysql
 type Field struct {
-	Name string
-	Type TypeCode
+	Name      string    // The name of the field, or the alias.
+	OrgName   string    // The name of the field. Empty for expressions.
+	Table     string    // The name of the table containing this field, or the alias.
+	OrgTable  string    // The name of the table. Empty for expressions.
+	Database  string    // The name of the database that the field comes from. Empty for expressions.
+	Type      TypeCode  // The type of the field.
+	Charset   int       // The character set number. 63 means binary data.
+	Length    int64     // The width of the field, as specified in the table definition.
+	MaxLength int64     // The maximum width of the field for the result set. Only available for DataTable.
+	Decimals  int       // The number of decimals for numeric fields, or the fractional seconds precision for temporal fields.
+	Flags     FieldFlag // Bit-flags that describe the field.
+}
+
+// Check the field is UNSIGNED.
+func (f *Field) IsUnsigned() bool {
+	return f.Flags&UNSIGNED_FLAG != 0
+}
+
+// Check the field can be NULL.
+func (f *Field) Nullable() bool {
+	return f.Flags&NOT_NULL_FLAG == 0
 }
 
-// Value can store any SQL value. NULL is stored as nil.
+// Check the field is part of a primary key.
+func (f *Field) IsPrimaryKey() bool {
+	return f.Flags&PRI_KEY_FLAG != 0
+}
+
+// Check the field is part of a unique key.
+func (f *Field) IsUniqueKey() bool {
+	return f.Flags&UNIQUE_KEY_FLAG != 0
+}
+
+// Check the field is BINARY, or a binary string (BINARY, VARBINARY, BLOB).
+func (f *Field) IsBinary() bool {
+	return f.Flags&BINARY_FLAG != 0
+}
+
+// Check the field is AUTO_INCREMENT.
+func (f *Field) IsAutoIncrement() bool {
+	return f.Flags&AUTO_INCREMENT_FLAG != 0
+}
+
+// Check the field is ZEROFILL.
+func (f *Field) IsZerofill() bool {
+	return f.Flags&ZEROFILL_FLAG != 0
+}
+
+// Check the field is an ENUM.
+func (f *Field) IsEnum() bool {
+	return f.Flags&ENUM_FLAG != 0
+}
+
+// Check the field is a SET.
+func (f *Field) IsSet() bool {
+	return f.Flags&SET_FLAG != 0
+}
+
+// Value can store any SQL value. NULL is stored as nil, empty value is stored as an empty but not nil slice.
 type Value struct {
 	isStmtValue bool
+	isUnsigned  bool
 	Type        TypeCode
 	Inner       []byte
 }
@@ -98,17 +270,35 @@ func (v *Value) IsNull() bool {
 	return v.Inner == nil
 }
 
-// Auto convert.
+// Auto convert. DATE, DATETIME and TIMESTAMP values are converted to time.Time in UTC, TIME values to time.Duration.
+// Integer values of prepared statement are converted to int64, or uint64 if the column is UNSIGNED.
+// NULL is converted to nil, other values are converted to string.
 func (v *Value) Interface() interface{} {
+	if v.IsNull() {
+		return nil
+	}
+	switch v.Type {
+	case MYSQL_TYPE_DATE, MYSQL_TYPE_NEWDATE, MYSQL_TYPE_DATETIME, MYSQL_TYPE_TIMESTAMP:
+		if t, err := v.Time(time.UTC); err == nil {
+			return t
+		}
+	case MYSQL_TYPE_TIME:
+		if d, err := v.Duration(); err == nil {
+			return d
+		}
+	}
 	if v.isStmtValue {
 		switch v.Type {
 		case MYSQL_TYPE_TINY, MYSQL_TYPE_YEAR, MYSQL_TYPE_SHORT, MYSQL_TYPE_INT24, MYSQL_TYPE_LONG, MYSQL_TYPE_LONGLONG:
+			if v.isUnsigned {
+				return v.getUint()
+			}
 			return v.getInt()
 		case MYSQL_TYPE_FLOAT, MYSQL_TYPE_DOUBLE:
 			return v.getFloat()
 		}
 	}
-	return string(v.Inner)
+	return v.String()
 }
 
 // Convert to int8 value.
@@ -131,6 +321,26 @@ func (v *Value) Int64() int64 {
 	return v.getInt()
 }
 
+// Convert to uint8 value.
+func (v *Value) Uint8() uint8 {
+	return uint8(v.getUint())
+}
+
+// Convert to uint16 value.
+func (v *Value) Uint16() uint16 {
+	return uint16(v.getUint())
+}
+
+// Convert to uint32 value.
+func (v *Value) Uint32() uint32 {
+	return uint32(v.getUint())
+}
+
+// Convert to uint64 value.
+func (v *Value) Uint64() uint64 {
+	return v.getUint()
+}
+
 // Convert to float32 value.
 func (v *Value) Float32() float32 {
 	return float32(v.getFloat())
@@ -141,27 +351,119 @@ func (v *Value) Float64() float64 {
 	return v.getFloat()
 }
 
+// Convert to int8 value, returns a *ConvertError if the value is NULL, invalid or out of range.
+func (v *Value) TryInt8() (int8, error) {
+	r, err := v.toInt("int8", math.MinInt8, math.MaxInt8)
+	return int8(r), err
+}
+
+// Convert to int16 value, returns a *ConvertError if the value is NULL, invalid or out of range.
+func (v *Value) TryInt16() (int16, error) {
+	r, err := v.toInt("int16", math.MinInt16, math.MaxInt16)
+	return int16(r), err
+}
+
+// Convert to int32 value, returns a *ConvertError if the value is NULL, invalid or out of range.
+func (v *Value) TryInt32() (int32, error) {
+	r, err := v.toInt("int32", math.MinInt32, math.MaxInt32)
+	return int32(r), err
+}
+
+// Convert to int64 value, returns a *ConvertError if the value is NULL, invalid or out of range.
+func (v *Value) TryInt64() (int64, error) {
+	return v.toInt("int64", math.MinInt64, math.MaxInt64)
+}
+
+// Convert to uint8 value, returns a *ConvertError if the value is NULL, invalid or out of range.
+func (v *Value) TryUint8() (uint8, error) {
+	r, err := v.toUint("uint8", math.MaxUint8)
+	return uint8(r), err
+}
+
+// Convert to uint16 value, returns a *ConvertError if the value is NULL, invalid or out of range.
+func (v *Value) TryUint16() (uint16, error) {
+	r, err := v.toUint("uint16", math.MaxUint16)
+	return uint16(r), err
+}
+
+// Convert to uint32 value, returns a *ConvertError if the value is NULL, invalid or out of range.
+func (v *Value) TryUint32() (uint32, error) {
+	r, err := v.toUint("uint32", math.MaxUint32)
+	return uint32(r), err
+}
+
+// Convert to uint64 value, returns a *ConvertError if the value is NULL, invalid or out of range.
+func (v *Value) TryUint64() (uint64, error) {
+	return v.toUint("uint64", math.MaxUint64)
+}
+
+// Convert to float32 value, returns a *ConvertError if the value is NULL or invalid.
+func (v *Value) TryFloat32() (float32, error) {
+	r, err := v.toFloat("float32")
+	return float32(r), err
+}
+
+// Convert to float64 value, returns a *ConvertError if the value is NULL or invalid.
+func (v *Value) TryFloat64() (float64, error) {
+	return v.toFloat("float64")
+}
+
+// Convert to sql.NullInt64 value. NULL is not an error.
+func (v *Value) NullInt64() (sql.NullInt64, error) {
+	if v.IsNull() {
+		return sql.NullInt64{}, nil
+	}
+	r, err := v.TryInt64()
+	return sql.NullInt64{Int64: r, Valid: err == nil}, err
+}
+
+// Convert to sql.NullInt32 value. NULL is not an error.
+func (v *Value) NullInt32() (sql.NullInt32, error) {
+	if v.IsNull() {
+		return sql.NullInt32{}, nil
+	}
+	r, err := v.TryInt32()
+	return sql.NullInt32{Int32: r, Valid: err == nil}, err
+}
+
+// Convert to sql.NullFloat64 value. NULL is not an error.
+func (v *Value) NullFloat64() (sql.NullFloat64, error) {
+	if v.IsNull() {
+		return sql.NullFloat64{}, nil
+	}
+	r, err := v.TryFloat64()
+	return sql.NullFloat64{Float64: r, Valid: err == nil}, err
+}
+
+// Convert to sql.NullString value.
+func (v *Value) NullString() sql.NullString {
+	if v.IsNull() {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: v.String(), Valid: true}
+}
+
+// Convert to sql.NullTime value. NULL is not an error.
+func (v *Value) NullTime(loc *time.Location) (sql.NullTime, error) {
+	if v.IsNull() {
+		return sql.NullTime{}, nil
+	}
+	r, err := v.Time(loc)
+	return sql.NullTime{Time: r, Valid: err == nil}, err
+}
+
 // Convert to int value.
 func (v *Value) getInt() int64 {
-	if v.isStmtValue {
-		switch v.Type {
-		case MYSQL_TYPE_TINY:
-			return int64(*(*int8)(bytePointer(v.Inner)))
-		case MYSQL_TYPE_YEAR:
-			fallthrough
-		case MYSQL_TYPE_SHORT:
-			return int64(*(*int16)(bytePointer(v.Inner)))
-		case MYSQL_TYPE_INT24:
-			fallthrough
-		case MYSQL_TYPE_LONG:
-			return int64(*(*int32)(bytePointer(v.Inner)))
-		case MYSQL_TYPE_LONGLONG:
-			return *(*int64)(bytePointer(v.Inner))
-		default:
-			panic("the value is not integer type")
-		}
+	r, err := v.toInt("int64", math.MinInt64, math.MaxInt64)
+	if err != nil {
+		panic(err)
 	}
-	r, err := strconv.ParseInt(byteString(v.Inner), 0, 64)
+	return r
+}
+
+// Convert to unsigned int value.
+func (v *Value) getUint() uint64 {
+	r, err := v.toUint("uint64", math.MaxUint64)
 	if err != nil {
 		panic(err)
 	}
@@ -170,25 +472,216 @@ func (v *Value) getInt() int64 {
 
 // Convert to float value.
 func (v *Value) getFloat() float64 {
-	if v.isStmtValue {
+	r, err := v.toFloat("float64")
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func (v *Value) convertError(target string, err error) error {
+	ce := &ConvertError{Type: v.Type, Target: target, Err: err}
+	if !v.isStmtValue || v.isText() {
+		ce.Value = string(v.Inner)
+	}
+	if ne, ok := err.(*strconv.NumError); ok {
+		ce.Err = ne.Err
+	}
+	return ce
+}
+
+// Check the value is in text format. All the values of text protocol are text,
+// only DECIMAL, string and BIT values of prepared statement are text.
+func (v *Value) isText() bool {
+	if !v.isStmtValue {
+		return true
+	}
+	switch v.Type {
+	case MYSQL_TYPE_TINY, MYSQL_TYPE_YEAR, MYSQL_TYPE_SHORT, MYSQL_TYPE_INT24, MYSQL_TYPE_LONG, MYSQL_TYPE_LONGLONG,
+		MYSQL_TYPE_FLOAT, MYSQL_TYPE_DOUBLE,
+		MYSQL_TYPE_DATE, MYSQL_TYPE_DATETIME, MYSQL_TYPE_TIMESTAMP, MYSQL_TYPE_TIME:
+		return false
+	}
+	return true
+}
+
+// Convert to int value, check the result in range [min, max].
+func (v *Value) toInt(target string, min, max int64) (int64, error) {
+	if v.IsNull() {
+		return 0, v.convertError(target, ErrNullValue)
+	}
+
+	var r int64
+	if v.isUnsigned {
+		u, err := v.toUint(target, math.MaxUint64)
+		if err != nil {
+			return 0, err
+		}
+		if u > uint64(max) {
+			return 0, v.convertError(target, strconv.ErrRange)
+		}
+		return int64(u), nil
+	} else if v.isText() {
+		var err error
+		if r, err = strconv.ParseInt(byteString(v.Inner), 10, 64); err != nil {
+			return 0, v.convertError(target, err)
+		}
+	} else {
+		p, ok := v.binary()
+		if !ok {
+			return 0, v.convertError(target, ErrTypeMismatch)
+		}
 		switch v.Type {
-		case MYSQL_TYPE_FLOAT:
-			return float64(*(*float32)(bytePointer(v.Inner)))
-		case MYSQL_TYPE_DOUBLE:
-			return *(*float64)(bytePointer(v.Inner))
+		case MYSQL_TYPE_TINY:
+			r = int64(*(*int8)(p))
+		case MYSQL_TYPE_YEAR, MYSQL_TYPE_SHORT:
+			r = int64(*(*int16)(p))
+		case MYSQL_TYPE_INT24, MYSQL_TYPE_LONG:
+			r = int64(*(*int32)(p))
+		case MYSQL_TYPE_LONGLONG:
+			r = *(*int64)(p)
 		default:
-			panic("the value is not float type")
+			return 0, v.convertError(target, ErrTypeMismatch)
 		}
 	}
-	r, err := strconv.ParseFloat(byteString(v.Inner), 64)
+
+	if r < min || r > max {
+		return 0, v.convertError(target, strconv.ErrRange)
+	}
+	return r, nil
+}
+
+// Convert to unsigned int value, check the result not greater than max.
+func (v *Value) toUint(target string, max uint64) (uint64, error) {
+	if v.IsNull() {
+		return 0, v.convertError(target, ErrNullValue)
+	}
+
+	var r uint64
+	if v.isText() {
+		var err error
+		if r, err = strconv.ParseUint(byteString(v.Inner), 10, 64); err != nil {
+			return 0, v.convertError(target, err)
+		}
+	} else if !v.isUnsigned {
+		i, err := v.toInt(target, 0, math.MaxInt64)
+		if err != nil {
+			return 0, err
+		}
+		r = uint64(i)
+	} else {
+		p, ok := v.binary()
+		if !ok {
+			return 0, v.convertError(target, ErrTypeMismatch)
+		}
+		switch v.Type {
+		case MYSQL_TYPE_TINY:
+			r = uint64(*(*uint8)(p))
+		case MYSQL_TYPE_YEAR, MYSQL_TYPE_SHORT:
+			r = uint64(*(*uint16)(p))
+		case MYSQL_TYPE_INT24, MYSQL_TYPE_LONG:
+			r = uint64(*(*uint32)(p))
+		case MYSQL_TYPE_LONGLONG:
+			r = *(*uint64)(p)
+		default:
+			return 0, v.convertError(target, ErrTypeMismatch)
+		}
+	}
+
+	if r > max {
+		return 0, v.convertError(target, strconv.ErrRange)
+	}
+	return r, nil
+}
+
+// Convert to float value.
+func (v *Value) toFloat(target string) (float64, error) {
+	if v.IsNull() {
+		return 0, v.convertError(target, ErrNullValue)
+	}
+
+	if v.isText() {
+		r, err := strconv.ParseFloat(byteString(v.Inner), 64)
+		if err != nil {
+			return 0, v.convertError(target, err)
+		}
+		return r, nil
+	}
+
+	p, ok := v.binary()
+	if !ok {
+		return 0, v.convertError(target, ErrTypeMismatch)
+	}
+	switch v.Type {
+	case MYSQL_TYPE_FLOAT:
+		return float64(*(*float32)(p)), nil
+	case MYSQL_TYPE_DOUBLE:
+		return *(*float64)(p), nil
+	case MYSQL_TYPE_TINY, MYSQL_TYPE_YEAR, MYSQL_TYPE_SHORT, MYSQL_TYPE_INT24, MYSQL_TYPE_LONG, MYSQL_TYPE_LONGLONG:
+		if v.isUnsigned {
+			r, err := v.toUint(target, math.MaxUint64)
+			return float64(r), err
+		}
+		r, err := v.toInt(target, math.MinInt64, math.MaxInt64)
+		return float64(r), err
+	}
+	return 0, v.convertError(target, ErrTypeMismatch)
+}
+
+// Convert to bool value. Numbers are true if not zero, text can be "true" or "false" too.
+func (v *Value) toBool(target string) (bool, error) {
+	if v.IsNull() {
+		return false, v.convertError(target, ErrNullValue)
+	}
+
+	if v.Type == MYSQL_TYPE_BIT {
+		for _, b := range v.Inner {
+			if b != 0 {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	if v.isText() {
+		if r, err := strconv.ParseBool(byteString(v.Inner)); err == nil {
+			return r, nil
+		}
+	}
+
+	r, err := v.toFloat(target)
 	if err != nil {
-		panic(err)
+		return false, err
 	}
-	return r
+	return r != 0, nil
+}
+
+// Get the pointer of prepared statement numeric value, returns false if the buffer is too short.
+func (v *Value) binary() (unsafe.Pointer, bool) {
+	size := 0
+	switch v.Type {
+	case MYSQL_TYPE_TINY:
+		size = 1
+	case MYSQL_TYPE_YEAR, MYSQL_TYPE_SHORT:
+		size = 2
+	case MYSQL_TYPE_INT24, MYSQL_TYPE_LONG, MYSQL_TYPE_FLOAT:
+		size = 4
+	case MYSQL_TYPE_LONGLONG, MYSQL_TYPE_DOUBLE:
+		size = 8
+	default:
+		return nil, false
+	}
+	if len(v.Inner) < size {
+		return nil, false
+	}
+	return bytePointer(v.Inner), true
 }
 
-// Convert to string value.
+// Convert to string value. NULL is converted to empty string.
 func (v *Value) String() string {
+	if v.IsNull() {
+		return ""
+	}
 	if v.isStmtValue {
 		switch v.Type {
 		// string
@@ -224,12 +717,24 @@ func (v *Value) String() string {
 		case MYSQL_TYPE_LONG:
 			fallthrough
 		case MYSQL_TYPE_LONGLONG:
+			if v.isUnsigned {
+				return strconv.FormatUint(v.getUint(), 10)
+			}
 			return strconv.FormatInt(v.getInt(), 10)
 		// float
 		case MYSQL_TYPE_FLOAT:
 			fallthrough
 		case MYSQL_TYPE_DOUBLE:
 			return strconv.FormatFloat(v.getFloat(), 'f', -1, 64)
+		// time
+		case MYSQL_TYPE_DATE:
+			fallthrough
+		case MYSQL_TYPE_DATETIME:
+			fallthrough
+		case MYSQL_TYPE_TIMESTAMP:
+			fallthrough
+		case MYSQL_TYPE_TIME:
+			return v.formatTime()
 		}
 	}
 	return string(v.Inner)
@@ -0,0 +1,86 @@
+package mysql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Warning, error or note generated by the last statement.
+type Warning struct {
+	Level   string // "Note", "Warning" or "Error".
+	Code    int    // Error number, see the ER_XXX constants.
+	Message string
+}
+
+// Get warning string.
+func (w Warning) String() string {
+	return fmt.Sprintf("%s %d: %s", w.Level, w.Code, w.Message)
+}
+
+// Warning error. Returned when ConnectionParams.StrictWarnings enabled and the statement generated warnings
+// or errors, notes are ignored. The statement has been executed, so the result is returned with it.
+type WarningError struct {
+	Warnings []Warning
+}
+
+// Get error string.
+func (we *WarningError) Error() string {
+	msgs := make([]string, len(we.Warnings))
+	for i, w := range we.Warnings {
+		msgs[i] = w.String()
+	}
+	return fmt.Sprintf("mysql: %d warnings: %s", len(we.Warnings), strings.Join(msgs, "; "))
+}
+
+// Get the warnings generated by the last statement, by SHOW WARNINGS.
+func (conn *Connection) Warnings() (warnings []Warning, err error) {
+	conn.do(func() {
+		warnings, err = conn.warnings()
+	})
+	return
+}
+
+func (conn *Connection) warnings() ([]Warning, error) {
+	res, err := conn.queryTable("SHOW WARNINGS")
+	if err != nil {
+		return nil, err
+	}
+
+	// Columns: Level, Code, Message.
+	rows := res.Rows()
+	warnings := make([]Warning, len(rows))
+	for i, row := range rows {
+		code, err := row[1].TryInt64()
+		if err != nil {
+			return nil, err
+		}
+		warnings[i] = Warning{
+			Level:   row[0].String(),
+			Code:    int(code),
+			Message: row[2].String(),
+		}
+	}
+	return warnings, nil
+}
+
+// Returns *WarningError if strict warnings enabled and the result has warnings, notes are ignored.
+func (conn *Connection) checkWarnings(res Result) error {
+	if !conn.params.StrictWarnings || res.WarningCount() == 0 {
+		return nil
+	}
+	warnings, err := conn.warnings()
+	if err != nil {
+		return err
+	}
+	n := 0
+	for _, w := range warnings {
+		if w.Level != "Note" {
+			warnings[n] = w
+			n++
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+	return &WarningError{warnings[:n]}
+}
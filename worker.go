@@ -0,0 +1,82 @@
+package mysql
+
+/*
+#include "cgo.h"
+*/
+import "C"
+import (
+	"runtime"
+	"sync"
+)
+
+// A goroutine locked to an OS thread, all C calls of a connection
+// run on it when ConnectionParams.DedicatedThread is enabled.
+// So the MYSQL handle is never used by two threads and the
+// per-thread client state can be released by mysql_thread_end().
+type worker struct {
+	mutex   sync.Mutex
+	calls   chan func()
+	done    chan interface{}
+	stopped bool
+}
+
+func newWorker() *worker {
+	w := &worker{
+		calls: make(chan func()),
+		done:  make(chan interface{}),
+	}
+	go w.loop()
+	return w
+}
+
+func (w *worker) loop() {
+	// Never unlock, the thread will be terminated when the goroutine exits.
+	runtime.LockOSThread()
+	C.my_worker_init()
+	for f := range w.calls {
+		w.done <- w.call(f)
+	}
+	C.my_worker_end()
+}
+
+func (w *worker) call(f func()) (p interface{}) {
+	defer func() {
+		p = recover()
+	}()
+	f()
+	return nil
+}
+
+// Run f on the worker thread and wait it finished. Calls are serialized.
+func (w *worker) do(f func()) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.stopped {
+		f()
+		return
+	}
+
+	w.calls <- f
+	if p := <-w.done; p != nil {
+		panic(p)
+	}
+}
+
+// Stop the worker after f run on it.
+func (w *worker) stop(f func()) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.stopped {
+		return
+	}
+
+	w.calls <- f
+	p := <-w.done
+	close(w.calls)
+	w.stopped = true
+	if p != nil {
+		panic(p)
+	}
+}
@@ -0,0 +1,249 @@
+package mysql
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Returned by the methods of XATx when the transaction state not allows the operation.
+var ErrXAState = errors.New("mysql: invalid XA transaction state")
+
+// XA transaction identifier.
+type XID struct {
+	GTRID    string // Global transaction identifier, at most 64 bytes.
+	BQUAL    string // Branch qualifier, at most 64 bytes.
+	FormatID uint32 // Format of GTRID and BQUAL. Zero means 1, the default of MySQL.
+}
+
+// Maximum length of GTRID and BQUAL in bytes.
+const xidPartMaxLen = 64
+
+// Get the xid used in XA statements. GTRID and BQUAL are hex literals, so they can contain any bytes.
+func (xid XID) String() string {
+	formatID := xid.FormatID
+	if formatID == 0 {
+		formatID = 1
+	}
+	return "X'" + hex.EncodeToString([]byte(xid.GTRID)) + "',X'" + hex.EncodeToString([]byte(xid.BQUAL)) + "'," + strconv.FormatUint(uint64(formatID), 10)
+}
+
+// Check the length of GTRID and BQUAL.
+func (xid XID) validate() error {
+	if len(xid.GTRID) > xidPartMaxLen {
+		return fmt.Errorf("mysql: XID GTRID is %d bytes, at most %d", len(xid.GTRID), xidPartMaxLen)
+	}
+	if len(xid.BQUAL) > xidPartMaxLen {
+		return fmt.Errorf("mysql: XID BQUAL is %d bytes, at most %d", len(xid.BQUAL), xidPartMaxLen)
+	}
+	return nil
+}
+
+// XA transaction state.
+type XAState int
+
+const (
+	XA_ACTIVE      XAState = iota // After XA START.
+	XA_IDLE                       // After XA END.
+	XA_PREPARED                   // After XA PREPARE.
+	XA_COMMITTED                  // After XA COMMIT.
+	XA_ROLLED_BACK                // After XA ROLLBACK.
+)
+
+// Get the name of state.
+func (state XAState) String() string {
+	switch state {
+	case XA_ACTIVE:
+		return "ACTIVE"
+	case XA_IDLE:
+		return "IDLE"
+	case XA_PREPARED:
+		return "PREPARED"
+	case XA_COMMITTED:
+		return "COMMITTED"
+	case XA_ROLLED_BACK:
+		return "ROLLED BACK"
+	}
+	return fmt.Sprintf("XAState(%d)", int(state))
+}
+
+// XA transaction branch on a connection.
+type XATx struct {
+	conn  *Connection
+	xid   XID
+	state XAState
+}
+
+// Start a XA transaction branch.
+func (conn *Connection) XAStart(xid XID) (*XATx, error) {
+	if err := xid.validate(); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Execute("XA START " + xid.String()); err != nil {
+		return nil, err
+	}
+	return &XATx{conn: conn, xid: xid, state: XA_ACTIVE}, nil
+}
+
+// Commit a prepared XA transaction by xid, e.g. the xid returned by XARecover().
+func (conn *Connection) XACommit(xid XID) error {
+	if err := xid.validate(); err != nil {
+		return err
+	}
+	_, err := conn.Execute("XA COMMIT " + xid.String())
+	return err
+}
+
+// Roll back a prepared XA transaction by xid, e.g. the xid returned by XARecover().
+func (conn *Connection) XARollback(xid XID) error {
+	if err := xid.validate(); err != nil {
+		return err
+	}
+	_, err := conn.Execute("XA ROLLBACK " + xid.String())
+	return err
+}
+
+// Get the XA transactions in PREPARED state.
+func (conn *Connection) XARecover() ([]XID, error) {
+	res, err := conn.QueryTable("XA RECOVER")
+	if err != nil {
+		return nil, err
+	}
+
+	// Columns: formatID, gtrid_length, bqual_length, data.
+	rows := res.Rows()
+	xids := make([]XID, 0, len(rows))
+	for _, row := range rows {
+		if len(row) < 4 {
+			return nil, errors.New("mysql: unexpected XA RECOVER result")
+		}
+		formatID, err := row[0].TryInt64()
+		if err != nil {
+			return nil, err
+		}
+		gtridLength, err := row[1].TryInt64()
+		if err != nil {
+			return nil, err
+		}
+		bqualLength, err := row[2].TryInt64()
+		if err != nil {
+			return nil, err
+		}
+		data := row[3].Inner
+		if gtridLength < 0 || bqualLength < 0 || gtridLength+bqualLength > int64(len(data)) {
+			return nil, errors.New("mysql: unexpected XA RECOVER result")
+		}
+		xids = append(xids, XID{
+			GTRID:    string(data[:gtridLength]),
+			BQUAL:    string(data[gtridLength : gtridLength+bqualLength]),
+			FormatID: uint32(formatID),
+		})
+	}
+	return xids, nil
+}
+
+// Get the connection of transaction.
+func (tx *XATx) Conn() *Connection {
+	return tx.conn
+}
+
+// Get the xid of transaction.
+func (tx *XATx) XID() XID {
+	return tx.xid
+}
+
+// Get the state of transaction.
+func (tx *XATx) State() XAState {
+	return tx.state
+}
+
+// End the transaction, ACTIVE to IDLE.
+func (tx *XATx) End() error {
+	return tx.transit("XA END", XA_IDLE, XA_ACTIVE)
+}
+
+// Prepare the transaction, IDLE to PREPARED.
+func (tx *XATx) Prepare() error {
+	return tx.transit("XA PREPARE", XA_PREPARED, XA_IDLE)
+}
+
+// Commit the transaction, PREPARED to COMMITTED.
+func (tx *XATx) Commit() error {
+	return tx.transit("XA COMMIT", XA_COMMITTED, XA_PREPARED)
+}
+
+// Commit the transaction without prepare, IDLE to COMMITTED.
+func (tx *XATx) CommitOnePhase() error {
+	if tx.state != XA_IDLE {
+		return tx.stateError("XA COMMIT ONE PHASE")
+	}
+	if _, err := tx.conn.Execute("XA COMMIT " + tx.xid.String() + " ONE PHASE"); err != nil {
+		tx.checkRolledBack("XA COMMIT", err)
+		return err
+	}
+	tx.state = XA_COMMITTED
+	return nil
+}
+
+// Roll back the transaction. An ACTIVE transaction is ended first.
+func (tx *XATx) Rollback() error {
+	if tx.state == XA_ACTIVE {
+		if err := tx.End(); err != nil {
+			if tx.state == XA_ROLLED_BACK {
+				return nil
+			}
+			return err
+		}
+	}
+	return tx.transit("XA ROLLBACK", XA_ROLLED_BACK, XA_IDLE, XA_PREPARED)
+}
+
+// Execute a non-query SQL in the transaction, only allowed in ACTIVE state.
+func (tx *XATx) Execute(sql string) (Result, error) {
+	if tx.state != XA_ACTIVE {
+		return nil, tx.stateError("execute")
+	}
+	return tx.conn.Execute(sql)
+}
+
+// Execute a query in the transaction and fill result into a DataTable, only allowed in ACTIVE state.
+func (tx *XATx) QueryTable(sql string) (DataTable, error) {
+	if tx.state != XA_ACTIVE {
+		return nil, tx.stateError("query")
+	}
+	return tx.conn.QueryTable(sql)
+}
+
+// Execute the XA statement if the transaction in one of the from states, and change state to.
+func (tx *XATx) transit(stmt string, to XAState, from ...XAState) error {
+	for _, state := range from {
+		if tx.state == state {
+			if _, err := tx.conn.Execute(stmt + " " + tx.xid.String()); err != nil {
+				tx.checkRolledBack(stmt, err)
+				return err
+			}
+			tx.state = to
+			return nil
+		}
+	}
+	return tx.stateError(stmt)
+}
+
+// Change state to ROLLED BACK if the XA statement returns XA_RBROLLBACK, XA_RBTIMEOUT or XA_RBDEADLOCK,
+// the server has rolled back the branch. A failed XA END or XA PREPARE leaves the branch rollback-only,
+// so finish it by XA ROLLBACK.
+func (tx *XATx) checkRolledBack(stmt string, err error) {
+	num, ok := errorNumber(err)
+	if !ok || (num != ER_XA_RBROLLBACK && num != ER_XA_RBTIMEOUT && num != ER_XA_RBDEADLOCK) {
+		return
+	}
+	if stmt == "XA END" || stmt == "XA PREPARE" {
+		tx.conn.Execute("XA ROLLBACK " + tx.xid.String())
+	}
+	tx.state = XA_ROLLED_BACK
+}
+
+func (tx *XATx) stateError(op string) error {
+	return fmt.Errorf("%w: can't %s in %v state", ErrXAState, op, tx.state)
+}